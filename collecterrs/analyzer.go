@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
+	"sort"
 	"strings"
 	"unicode"
 
@@ -14,246 +16,463 @@ import (
 )
 
 type UsecaseAnalysis struct {
-	returnedProviders map[string][]ProviderCall
+	packages     map[string]*packages.Package // Loaded packages by import path
+	module       string                       // Module path of the analyzed project
+	errsPkgPath  string                       // Import path of the package declaring errs.ServiceError
+	registry     *ErrorRegistry
+	funcs        *FuncIndex
+	wrappers     *WrapperIndex
+	providers    *ProviderIndex
+	providerPkgs map[string]bool            // Import paths of the packages declaring the Providers structs
+	summaries    *SummaryCache[*types.Func] // Errors of the called functions of the module
+	ssa          *SSAAnalyzer               // Set in ModeSSA
+	cfg          Config
+	services     []*Service
+	warnings     []string
+	diagnostics  []Diagnostic
+	graph        []GraphEdge // Calls between usecases, set by LinkProviderErrors
+	moduleDir    string      // Absolute directory of the module, positions are reported relative to it
 }
 
 func NewUsecaseAnalysis() *UsecaseAnalysis {
+	funcs := NewFuncIndex()
 	return &UsecaseAnalysis{
-		returnedProviders: make(map[string][]ProviderCall),
+		packages:     make(map[string]*packages.Package),
+		registry:     NewErrorRegistry(),
+		funcs:        funcs,
+		wrappers:     NewWrapperIndex(funcs),
+		providers:    NewProviderIndex(),
+		providerPkgs: make(map[string]bool),
+		summaries:    NewSummaryCache[*types.Func](),
+		cfg:          DefaultConfig(),
 	}
 }
 
-func (ua *UsecaseAnalysis) Analyze(servicesPath string, moduleName string, verbose bool) (map[string]map[string][]string, error) {
-	var services []string
+// Analyze collects possible errors of every usecase of the services found in the project
+func (ua *UsecaseAnalysis) Analyze(cfg Config, verbose bool) (*Report, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	ua.cfg = cfg
+	moduleName, err := readModuleName(cfg.ModuleDir)
+	if err != nil {
+		return nil, err
+	}
+	ua.module = moduleName
+	if ua.moduleDir, err = filepath.Abs(cfg.ModuleDir); err != nil {
+		return nil, err
+	}
+	if ua.errsPkgPath, err = cfg.ErrsImport(); err != nil {
+		return nil, err
+	}
 
-	entries, err := os.ReadDir(servicesPath)
+	serviceDirs, err := globDirs(cfg.ModuleDir, cfg.Services)
 	if err != nil {
 		return nil, err
 	}
+	if len(serviceDirs) == 0 {
+		return nil, fmt.Errorf("no services found by pattern %s in %s", cfg.Services, cfg.ModuleDir)
+	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			services = append(services, toCamelCase(entry.Name()))
+	var patterns []string
+	for _, serviceDir := range serviceDirs {
+		service := &Service{
+			Name: toCamelCase(path.Base(serviceDir)),
+			Dir:  serviceDir,
+			Pkg:  moduleName + "/" + serviceDir,
 		}
+		for _, layer := range cfg.Layers {
+			dirs, err := globDirs(cfg.ModuleDir, filepath.Join(serviceDir, layer.Package))
+			if err != nil {
+				return nil, err
+			}
+			var layerPkgs []string
+			for _, dir := range dirs {
+				layerPkgs = append(layerPkgs, moduleName+"/"+dir)
+			}
+			service.Layers = append(service.Layers, layerPkgs)
+		}
+		ua.services = append(ua.services, service)
+		patterns = append(patterns, service.Pkg+"/...")
 	}
 
-	errs := map[string]map[string][]string{}
-	handledErrs := map[string]map[string]map[string]bool{}
+	providerDirs, err := globDirs(cfg.ModuleDir, cfg.Providers)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range providerDirs {
+		ua.providerPkgs[moduleName+"/"+dir] = true
+		patterns = append(patterns, moduleName+"/"+dir)
+	}
 
-	// first collect errors for each service separately, save references to providers except storage
-	for _, service := range services {
-		// Use the full module path for the package
-		storageErrs, _, err := ua.AnalyzePkg(fmt.Sprintf("%s/services/%s/storage", moduleName, service), nil, verbose)
-		if err != nil {
-			return nil, err
+	// load all service packages at once, so that dependencies are type-checked only one time
+	if _, err := ua.loadPackages(cfg.ModuleDir, verbose, patterns...); err != nil {
+		return nil, err
+	}
+	if cfg.Mode == ModeSSA {
+		var pkgs []*packages.Package
+		for _, pkgPath := range sortedKeys(ua.packages) {
+			pkgs = append(pkgs, ua.packages[pkgPath])
+		}
+		ua.ssa = NewSSAAnalyzer(pkgs, moduleName, ua.errsPkgPath, ua.registry, ua.funcs, ua.providers)
+	}
+	for _, service := range ua.services {
+		service.Proto = ua.findProtoService(service)
+		if verbose && service.Proto != nil {
+			debugf("Service %s registers proto service %s %v", service.Name, service.Proto.Name, service.Proto.Methods)
 		}
-		serviceErrs, handleds, err := ua.AnalyzePkg(fmt.Sprintf("%s/services/%s/usecase", moduleName, service), storageErrs, verbose)
-		errs[service] = serviceErrs
-		handledErrs[service] = handleds
 	}
 
-	ua.LinkProviderErrors(errs, handledErrs, verbose)
+	errs := map[string]map[string]*FuncErrors{}
+
+	// first collect errors for each service separately, save references to providers except service layers
+	for _, s := range ua.services {
+		service := s.Name
+		// errors of the lower layers by the called method
+		layerErrs := map[*types.Func]*FuncErrors{}
+		for i, layer := range cfg.Layers {
+			for _, pkgPath := range s.Layers[i] {
+				pkgErrs, err := ua.AnalyzePkg(pkgPath, layer, layerErrs, verbose)
+				if err != nil {
+					return nil, err
+				}
+				if i < len(cfg.Layers)-1 {
+					// the layer folds into the upper ones
+					for method, fe := range pkgErrs {
+						layerErrs[method] = fe
+					}
+					continue
+				}
+				if errs[service] == nil {
+					errs[service] = map[string]*FuncErrors{}
+				}
+				for method, fe := range pkgErrs {
+					errs[service][method.Name()] = fe
+				}
+			}
+		}
+	}
 
-	return errs, nil
+	catalogue := ua.LinkProviderErrors(errs, verbose)
+	sort.SliceStable(ua.diagnostics, func(i, j int) bool {
+		a, b := ua.diagnostics[i].pos, ua.diagnostics[j].pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	report := &Report{Services: catalogue, Diagnostics: ua.diagnostics, Handled: ua.handledErrors(errs), Graph: ua.graph, Protos: map[string]*ProtoService{}}
+	for _, service := range ua.services {
+		if service.Proto != nil {
+			report.Protos[service.Name] = service.Proto
+		}
+	}
+	return report, nil
 }
 
-func (ua *UsecaseAnalysis) LinkProviderErrors(errs map[string]map[string][]string, handledErrs map[string]map[string]map[string]bool, verbose bool) {
-	// insert errors from called providers
-	re := regexp.MustCompile(`^\[([a-zA-Z0-9]+)\]\.([a-zA-Z0-9]+)$`)
-	linkExists := true
-	for linkExists {
-		linkExists = false
-		for serviceName, serviceErrs := range errs {
-			for usecaseName, usecaseErrs := range serviceErrs {
-				newErrs := []string{}
-				for _, usecaseErr := range usecaseErrs {
-					matches := re.FindStringSubmatch(usecaseErr)
-					if len(matches) < 1 { // not a provider - save the error
-						newErrs = append(newErrs, usecaseErr)
-						continue
-					}
-					nestedServiceName := toCamelCase(matches[1])
-					s, ok := errs[nestedServiceName]
-					if !ok { // external provider, not our service - remove it, it definitely won't return named errors
-						if verbose {
-							fmt.Printf("[DEBUG] Remove external provider %s from %s.%s\n", usecaseErr, serviceName, usecaseName)
-						}
-						continue
-					}
-					nestedErrs, ok := s[matches[2]]
-					if !ok {
-						if verbose {
-							fmt.Printf("[DEBUG] Remove service provider %s from %s.%s [no nested errs]\n", usecaseErr, serviceName, usecaseName)
-						}
-						continue
-					}
-					for _, nestedErr := range nestedErrs {
-						errWithoutDetails := strings.Split(nestedErr, " ")[0]
-						if handledErrs[serviceName][usecaseName][errWithoutDetails] {
-							if verbose {
-								fmt.Printf("[DEBUG] Skip handled error %s.%s.%s \n", serviceName, usecaseName, nestedErr)
-							}
-							continue
-						}
-						nestedMatches := re.FindStringSubmatch(nestedErr)
-						if len(nestedMatches) > 1 {
-							linkExists = true // handle nested provider call
-							newErrs = append(newErrs, nestedErr)
-						} else {
-							newErrs = append(newErrs, nestedServiceName+"."+nestedErr)
-						}
-					}
-				}
-				errs[serviceName][usecaseName] = newErrs
+// handledErrors lists the checks excluding errors from the usecases
+func (ua *UsecaseAnalysis) handledErrors(errs map[string]map[string]*FuncErrors) []HandledError {
+	var handled []HandledError
+	for _, service := range sortedKeys(errs) {
+		for _, usecase := range sortedKeys(errs[service]) {
+			fe := errs[service][usecase]
+			for _, code := range sortedKeys(fe.Handled) {
+				handled = append(handled, HandledError{Usecase: service + "." + usecase, Code: code, Pos: ua.position(fe.Handled[code])})
 			}
 		}
 	}
+	return handled
 }
 
-func (ua *UsecaseAnalysis) AnalyzePkg(pkgPath string, extraErrs map[string][]string, verbose bool) (map[string][]string, map[string]map[string]bool, error) {
-	// Get the current working directory
-	cwd, err := os.Getwd()
+// globDirs returns directories matching the pattern, relative to the root and slash-separated
+func globDirs(root, pattern string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(root, pattern))
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get current working directory: %w", err)
+		return nil, fmt.Errorf("bad pattern %s: %w", pattern, err)
 	}
+	var dirs []string
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil || !info.IsDir() {
+			continue
+		}
+		rel, err := filepath.Rel(root, match)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, filepath.ToSlash(rel))
+	}
+	return dirs, nil
+}
 
-	// Extract the module name from the package path
-	parts := strings.Split(pkgPath, "/")
-	var projectDir string
-	if len(parts) > 0 {
-		// Find the directory that contains the go.mod file for this module
-		moduleDir := findModuleDir(cwd, parts[0])
-		if moduleDir != "" {
-			projectDir = moduleDir
-		} else {
-			projectDir = cwd
+// targetService returns the service the provider calls: the service registering the proto service
+// of the gRPC client, or the service named as the provider field for other providers
+func (ua *UsecaseAnalysis) targetService(call ProviderCall) string {
+	if call.Client == "" {
+		return toCamelCase(call.Provider)
+	}
+	for _, s := range ua.services {
+		if s.Proto != nil && s.Proto.Key() == call.Client {
+			return s.Name
 		}
-	} else {
-		projectDir = cwd
 	}
+	return "" // gRPC service outside of the project
+}
 
-	if verbose {
-		fmt.Printf("[DEBUG] Using project directory: %s for package: %s\n", projectDir, pkgPath)
+// newErrorEntry describes the named error returned by the usecase
+func (ua *UsecaseAnalysis) newErrorEntry(e NamedError, serviceName, usecaseID string) ErrorEntry {
+	entry := ErrorEntry{
+		Code:    e.Code,
+		Service: serviceName,
+		Details: e.Details,
+		Chain:   []string{usecaseID},
 	}
+	if e.Decl != nil {
+		entry.Type = e.Decl.Type
+		entry.Description = e.Decl.Description
+		entry.Var = e.Decl.Var
+		if e.Decl.Pos.IsValid() {
+			entry.Declared = ua.position(e.Decl.Pos)
+		}
+	}
+	return entry
+}
 
-	cfg := &packages.Config{
-		Mode: packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedSyntax,
-		Dir:  projectDir,
+// AnalyzePkg collects errors of the usecases or of the layer methods of the package.
+// Usecases are keyed by their declarations, layer methods by the methods the upper layers call
+// through the provider field of the layer. Calls of the lower layers are replaced with their errors from extraErrs
+func (ua *UsecaseAnalysis) AnalyzePkg(pkgPath string, layer Layer, extraErrs map[*types.Func]*FuncErrors, verbose bool) (map[*types.Func]*FuncErrors, error) {
+	projectDir := ua.cfg.ModuleDir
+	if projectDir == "" {
+		// Extract the module name from the package path
+		dir, err := findProjectDir(strings.Split(pkgPath, "/")[0])
+		if err != nil {
+			return nil, err
+		}
+		projectDir = dir
 	}
-	isUsecase := strings.HasSuffix(pkgPath, "usecase")
-	isStorage := strings.HasSuffix(pkgPath, "storage")
 
-	pkgs, err := packages.Load(cfg, pkgPath)
 	if verbose {
-		for _, pkg := range pkgs {
-			fmt.Printf("Package: %s\n", pkg.ID)
-			fmt.Printf("Files: %v\n", pkg.GoFiles)
-			fmt.Printf("Syntax trees: %d\n", len(pkg.Syntax))
-		}
+		debugf("Using project directory: %s for package: %s", projectDir, pkgPath)
 	}
+
+	isUsecase := layer.Name == ua.cfg.UsecaseLayer().Name
+
+	pkgs, err := ua.loadPackages(projectDir, verbose, pkgPath)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to load package: %w", err)
+		return nil, err
 	}
 
-	results := make(map[string][]string)
-	handledErrors := make(map[string]map[string]bool)
+	results := make(map[*types.Func]*FuncErrors)
 
 	for _, pkg := range pkgs {
-		pf := collectPackageFunctions(pkgs[0])
-		if verbose {
-			fmt.Printf("[DEBUG] Package functions: %+v\n", pf)
+		service := ua.serviceOf(pkg.PkgPath)
+		// analyzed declarations → keys of their results
+		keys := map[*ast.FuncDecl][]*types.Func{}
+		if isUsecase {
+			for decl := range ua.usecaseMethods(pkg, service) {
+				keys[decl] = []*types.Func{pkg.TypesInfo.Defs[decl.Name].(*types.Func)}
+			}
+		} else {
+			for called, decl := range ua.layerMethods(pkg, layer) {
+				keys[decl] = append(keys[decl], called)
+			}
 		}
+		resolver := NewErrorResolver(pkg.TypesInfo, ua.errsPkgPath, ua.registry, ua.wrappers)
 		for _, file := range pkg.Syntax {
-			filename := getFilename(file, pkg.Fset)
 			ast.Inspect(file, func(n ast.Node) bool {
 				fn, ok := n.(*ast.FuncDecl)
-				if !ok || (isUsecase && !isUsecaseMethod(filename, fn)) {
+				if !ok || len(keys[fn]) == 0 {
 					return true
 				}
 
-				var errors []string
-				errtracker := NewErrorVarTracker()
-				errhandler := NewErrorHandler()
-				providerTracker := NewProviderTracker()
-
 				if verbose {
-					fmt.Println("Start analyze " + fn.Name.Name)
+					debugf("Start analyze %s", fn.Name.Name)
+				}
+				errors := &FuncErrors{}
+				if ua.ssa != nil {
+					errors = ua.ssa.FuncErrors(pkg, fn)
+				} else {
+					errtracker := NewErrorVarTracker(resolver)
+					providerTracker := NewProviderTracker(ua.providers, pkg)
+					ua.analyzeFunction(fn, errtracker, providerTracker, errors)
+
+					if verbose {
+						debugf("Function: %s", fn.Name.Name)
+						debugf("Tracked errors: %v", errtracker.errorVars)
+						debugf("Handled errors by call: %v", providerTracker.handlers)
+						debugf("Provider calls: %v", providerTracker.Calls)
+					}
 				}
-				ua.analyzeFunction(fn, pf, make(map[string]bool), errtracker, errhandler, providerTracker, &errors)
-
 				if verbose {
-					fmt.Printf("[DEBUG] Function: %s\n", fn.Name.Name)
-					fmt.Printf("[DEBUG] Tracked errors: %v\n", errtracker.errorVars)
-					fmt.Printf("[DEBUG] Handled errors: %v\n", errhandler.handledErrors)
-					fmt.Printf("[DEBUG] Provider calls: %v\n", providerTracker.Calls)
-					fmt.Printf("[DEBUG] Result errors: %v\n", errors)
+					debugf("Result errors: %v %v, handled: %v", errors.Errors, errors.Calls, sortedKeys(errors.Handled))
 				}
 
-				name := fn.Name.Name
-				if isStorage {
-					name = fmt.Sprintf("[Storage].%s", name)
+				result := &FuncErrors{Pos: pkg.Fset.Position(fn.Name.Pos())}
+				for _, e := range errors.Errors {
+					result.AddError(e)
 				}
-				for _, e := range unique(errors) {
-					if nestedErrs, ok := extraErrs[e]; ok {
-						for _, er := range nestedErrs {
-							if !errhandler.handledErrors[er] {
-								results[name] = append(results[name], er)
+				result.AddHandled(errors.Handled)
+				for _, call := range errors.Calls {
+					if nestedErrs, ok := extraErrs[call.method]; ok && call.method != nil {
+						// the errors of the lower layer pass through the call and the calls leading to it
+						via := call.path()
+						for _, e := range nestedErrs.Errors {
+							if !call.Handled.Has(e.Code) {
+								result.AddError(e.at(via...))
 							}
 						}
-					} else if !strings.HasPrefix(e, "[Storage]") {
-						results[name] = append(results[name], e)
+						for _, c := range nestedErrs.Calls {
+							result.AddCall(c.WithHandled(call.Handled).at(via...))
+						}
+						result.AddHandled(nestedErrs.Handled)
+					} else if !ua.cfg.isLayerProvider(call.Provider) {
+						result.AddCall(call)
+					} else {
+						ua.drop(service.Name+"."+fn.Name.Name, call, ReasonUnknownMethod)
 					}
 				}
-				handledErrors[name] = errhandler.handledErrors
+				for _, key := range keys[fn] {
+					results[key] = result
+				}
 				return true
 			})
 		}
 	}
-	return results, handledErrors, nil
+	return results, nil
 }
 
-func isUsecaseMethod(filename string, fn *ast.FuncDecl) bool {
-	return fn.Recv != nil &&
-		strings.EqualFold(fn.Name.Name, filename)
+// loadPackages loads packages with syntax and full type information.
+// Loaded packages and their dependencies are cached by import path,
+// so the type-checked dependencies are shared between the analyzed packages
+func (ua *UsecaseAnalysis) loadPackages(projectDir string, verbose bool, pkgPaths ...string) ([]*packages.Package, error) {
+	var missing []string
+	for _, pkgPath := range pkgPaths {
+		if _, ok := ua.packages[pkgPath]; !ok {
+			missing = append(missing, pkgPath)
+		}
+	}
+
+	if len(missing) > 0 {
+		cfg := &packages.Config{
+			Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax | packages.NeedImports |
+				packages.NeedDeps | packages.NeedTypes | packages.NeedTypesInfo,
+			Dir: projectDir,
+		}
+		pkgs, err := packages.Load(cfg, missing...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load package: %w", err)
+		}
+		packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+			if _, ok := ua.packages[pkg.PkgPath]; ok {
+				return
+			}
+			ua.packages[pkg.PkgPath] = pkg
+			// collect declarations of named errors to report their real codes
+			ua.registry.Collect(pkg)
+			if ua.module != "" && (pkg.PkgPath == ua.module || strings.HasPrefix(pkg.PkgPath, ua.module+"/")) {
+				// functions of the module are followed into from other packages
+				ua.funcs.Collect(pkg)
+				for _, conflict := range ua.providers.Collect(pkg, ua.providerPkgs[pkg.PkgPath]) {
+					ua.warnf("%s: provider field %s, calls are attributed to the first name", pkg.PkgPath, conflict)
+				}
+			}
+		})
+		for _, pkg := range pkgs {
+			// keep requested packages even if they were not found, to not load them again
+			if _, ok := ua.packages[pkg.ID]; !ok {
+				ua.packages[pkg.ID] = pkg
+			}
+			if verbose {
+				debugf("Loaded package %s: %d files", pkg.ID, len(pkg.Syntax))
+			}
+		}
+	}
+
+	var result []*packages.Package
+	for _, pkgPath := range pkgPaths {
+		if pkg, ok := ua.packages[pkgPath]; ok {
+			result = append(result, pkg)
+		}
+	}
+	return result, nil
 }
 
-func getFilename(file *ast.File, fset *token.FileSet) string {
-	name := fset.File(file.Pos()).Name()
-	return strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+// drop records the provider call dropped while linking the usecase
+func (ua *UsecaseAnalysis) drop(usecase string, call ProviderCall, reason string) {
+	d := Diagnostic{
+		Usecase:      usecase,
+		Call:         call.String(),
+		Reason:       reason,
+		Pos:          ua.position(call.Pos),
+		Acknowledged: reason == ReasonExternal && ua.cfg.isExternal(call),
+		pos:          call.Pos,
+	}
+	for _, existing := range ua.diagnostics {
+		if existing == d {
+			return
+		}
+	}
+	ua.diagnostics = append(ua.diagnostics, d)
 }
 
-type PackageFunctions struct {
-	FuncDecls map[string]*ast.FuncDecl // Function name -> AST-node
-	Methods   map[string]*ast.FuncDecl // Struct methods
+// position formats the position relative to the module directory
+func (ua *UsecaseAnalysis) position(pos token.Position) string {
+	if rel, err := filepath.Rel(ua.moduleDir, pos.Filename); err == nil && pos.Filename != "" {
+		pos.Filename = filepath.ToSlash(rel)
+	}
+	return pos.String()
 }
 
-// collect functions to analyze them when we encounter a call in the code
-// currently only functions of the passed package
-func collectPackageFunctions(pkg *packages.Package) *PackageFunctions {
-	pf := &PackageFunctions{
-		FuncDecls: make(map[string]*ast.FuncDecl),
-		Methods:   make(map[string]*ast.FuncDecl),
-	}
-
-	for _, file := range pkg.Syntax {
-		ast.Inspect(file, func(n ast.Node) bool {
-			switch fn := n.(type) {
-			case *ast.FuncDecl:
-				if fn.Recv != nil {
-					// store by the scheme VariableName.Method to find during ast analysis
-					//typeName := exprToString(fn.Recv.List[0].Type)
-					typeVal := fn.Recv.List[0].Names[0]
-					key := fmt.Sprintf("%s.%s", typeVal.Name, fn.Name.Name)
-					pf.Methods[key] = fn
-				} else {
-					// usual function
-					pf.FuncDecls[fn.Name.Name] = fn
-				}
-			}
-			return true
-		})
+// Warnings returns problems found during the analysis, which do not stop it
+func (ua *UsecaseAnalysis) Warnings() []string {
+	return ua.warnings
+}
+
+func (ua *UsecaseAnalysis) warnf(format string, args ...any) {
+	ua.warnings = append(ua.warnings, fmt.Sprintf(format, args...))
+}
+
+// debugf writes the verbose output to stderr, stdout is left for the results
+func debugf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+}
+
+// funcSummary returns errors of a function of the module.
+// Summaries are memoised, so helpers called several times or shared by several services are analyzed once,
+// and each call site applies its own handled errors to the summary
+func (ua *UsecaseAnalysis) funcSummary(fn *types.Func) *FuncErrors {
+	fn = fn.Origin()
+	return ua.summaries.Summary(fn, func(fe *FuncErrors) {
+		d, ok := ua.funcs.Lookup(fn)
+		if !ok {
+			return
+		}
+		resolver := NewErrorResolver(d.Pkg.TypesInfo, ua.errsPkgPath, ua.registry, ua.wrappers)
+		ua.analyzeFunction(d.Decl, NewErrorVarTracker(resolver), NewProviderTracker(ua.providers, d.Pkg), fe)
+	})
+}
+
+// returnedCalls returns provider calls whose errors the called function of the module returns directly
+func (ua *UsecaseAnalysis) returnedCalls(call *ast.CallExpr, pkg *packages.Package) []ProviderCall {
+	d, ok := ua.funcs.Resolve(call, pkg.TypesInfo)
+	if !ok {
+		return nil
+	}
+	site := callSite(d.Func, pkg.Fset.Position(call.Pos()))
+	var calls []ProviderCall
+	for _, c := range ua.funcSummary(d.Func).Returned {
+		calls = append(calls, c.at(site))
 	}
-	return pf
+	return calls
+}
+
+// callSite returns the site of the call of the function of the module
+func callSite(fn *types.Func, pos token.Position) Site {
+	return Site{Kind: SiteCall, Name: funcName(fn), pos: pos}
 }
 
 // toCamelCase converts a string to camelCase format
@@ -280,92 +499,75 @@ func toCamelCase(s string) string {
 	return result
 }
 
-func exprToString(e ast.Expr) string {
-	switch t := e.(type) {
-	case *ast.Ident:
-		return t.Name
-	case *ast.StarExpr:
-		return exprToString(t.X)
-	case *ast.SelectorExpr:
-		return exprToString(t.X) + "." + t.Sel.Name
-	default:
-		return fmt.Sprintf("%T", t)
-	}
-}
-
 func (ua *UsecaseAnalysis) analyzeFunction(
 	fn *ast.FuncDecl,
-	pf *PackageFunctions,
-	visited map[string]bool,
 	errtracker *ErrorVarTracker,
-	errhandler *ErrorHandler,
 	providerTracker *ProviderTracker,
-	errors *[]string,
+	errors *FuncErrors,
 ) {
-	if visited[fn.Name.Name] {
-		return
-	}
-	visited[fn.Name.Name] = true
-
 	// Collect information about errors
 	ast.Inspect(fn.Body, func(n ast.Node) bool {
-		providerTracker.Track(n, ua)
 		errtracker.Track(n)
-		errhandler.Inspect(n, errtracker)
+		return true
+	})
+	// Errors handled after each call, then calls saved in variables
+	providerTracker.AddHandlers(callSiteHandlers(fn.Body, errtracker))
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		providerTracker.Track(n, ua)
 		return true
 	})
 
-	var funcProviders []ProviderCall
 	ast.Inspect(fn.Body, func(n ast.Node) bool {
 		switch node := n.(type) {
 		case *ast.ReturnStmt:
 			ua.checkReturnStatement(node, errtracker, providerTracker, errors)
-			for _, expr := range node.Results {
-				if ident, ok := expr.(*ast.Ident); ok {
-					if calls, exists := providerTracker.Calls[ident.Name]; exists {
-						funcProviders = append(funcProviders, calls...)
-					}
-				} else if call, ok := expr.(*ast.CallExpr); ok {
-					if provider, method, ok := extractProviderMethod(call); ok {
-						funcProviders = append(funcProviders, ProviderCall{provider, method})
+			for _, result := range node.Results {
+				for _, expr := range errtracker.resolver.Unwrap(result) {
+					if ident, ok := expr.(*ast.Ident); ok {
+						for _, call := range providerTracker.VarCalls(ident) {
+							errors.AddReturned(call)
+						}
+					} else if call, ok := expr.(*ast.CallExpr); ok {
+						if providerCall, ok := providerTracker.Call(call); ok {
+							errors.AddReturned(providerCall)
+						}
 					}
 				}
 			}
 		case *ast.CallExpr:
-			ua.analyzeCallExpression(node, pf, visited, errtracker, errhandler, providerTracker, errors)
+			ua.analyzeCallExpression(node, errtracker, providerTracker, errors)
 		}
 		return true
 	})
-	// Save function providers to cache
-	ua.returnedProviders[fn.Name.Name] = funcProviders
 }
 
 func (ua *UsecaseAnalysis) analyzeCallExpression(
 	call *ast.CallExpr,
-	pf *PackageFunctions,
-	visited map[string]bool,
 	errtracker *ErrorVarTracker,
-	errhandler *ErrorHandler,
 	providerTracker *ProviderTracker,
-	errors *[]string,
+	errors *FuncErrors,
 ) {
-	switch fn := call.Fun.(type) {
-	case *ast.SelectorExpr:
-		typeName := exprToString(fn.X)
-		key := fmt.Sprintf("%s.%s", typeName, fn.Sel.Name)
-		if decl, exists := pf.Methods[key]; exists {
-			ua.analyzeFunction(decl, pf, visited, errtracker, errhandler, providerTracker, errors)
-		}
-
-	case *ast.Ident:
-		// function call
-		if decl, exists := pf.FuncDecls[fn.Name]; exists {
-			ua.analyzeFunction(decl, pf, visited, errtracker, errhandler, providerTracker, errors)
+	// Save provider calls, their errors are inserted from the providers
+	if providerCall, ok := providerTracker.Call(call); ok {
+		errors.AddCall(providerCall)
+		return
+	}
+	d, ok := ua.funcs.Resolve(call, errtracker.resolver.info)
+	if !ok {
+		return
+	}
+	// Functions of the module, ex: helpers of the package, pkg/validation or methods of injected services
+	handled := providerTracker.handlers[call]
+	errors.AddHandled(handled)
+	summary := ua.funcSummary(d.Func)
+	site := callSite(d.Func, providerTracker.pkg.Fset.Position(call.Pos()))
+	for _, e := range summary.Errors {
+		if !handled.Has(e.Code) {
+			errors.AddError(e.at(site))
 		}
 	}
-	// Save provider calls
-	if provider, method, ok := extractProviderMethod(call); ok {
-		*errors = append(*errors, fmt.Sprintf("[%s].%s", provider, method))
+	for _, c := range summary.Calls {
+		errors.AddCall(c.WithHandled(handled).at(site))
 	}
 }
 
@@ -373,48 +575,42 @@ func (ua *UsecaseAnalysis) checkReturnStatement(
 	ret *ast.ReturnStmt,
 	errtracker *ErrorVarTracker,
 	providerTracker *ProviderTracker,
-	errors *[]string,
+	errors *FuncErrors,
 ) {
-	for _, expr := range ret.Results {
-		switch e := expr.(type) {
-		case *ast.SelectorExpr:
-			if strings.HasSuffix(e.Sel.Name, "Error") {
-				if ident, ok := e.X.(*ast.Ident); ok && strings.HasPrefix(ident.Name, "errs") {
-					*errors = append(*errors, strings.TrimSuffix(e.Sel.Name, "Error"))
+	for _, result := range ret.Results {
+		site := Site{Kind: SiteReturn, Name: types.ExprString(result), pos: providerTracker.pkg.Fset.Position(ret.Pos())}
+		for _, namedErr := range errtracker.getErrors(result) {
+			errors.AddError(namedErr.at(site))
+		}
+		// errors wrapped with fmt.Errorf("...: %w", err) are returned as well
+		for _, expr := range errtracker.resolver.Unwrap(result) {
+			switch e := expr.(type) {
+			case *ast.CallExpr:
+				// Direct provider calls
+				if providerCall, ok := providerTracker.Call(e); ok {
+					errors.AddCall(providerCall)
 				}
-			}
-		case *ast.CallExpr:
-			// Direct provider calls
-			if provider, method, ok := extractProviderMethod(e); ok {
-				*errors = append(*errors, fmt.Sprintf("[%s].%s", provider, method))
-			}
-			if code := errtracker.getErrorCode(e); code != "" {
-				*errors = append(*errors, code)
-			}
-
-		case *ast.Ident:
-			if code, exists := errtracker.errorVars[e.Name]; exists {
-				*errors = append(*errors, code)
-			}
-			if calls, exists := providerTracker.Calls[e.Name]; exists {
-				for _, call := range calls {
-					*errors = append(*errors, call.String())
+			case *ast.Ident:
+				for _, call := range providerTracker.VarCalls(e) {
+					errors.AddCall(call)
 				}
 			}
 		}
 	}
 }
 
-func unique(input []string) []string {
-	keys := make(map[string]bool)
-	list := []string{}
-	for _, entry := range input {
-		if _, value := keys[entry]; !value {
-			keys[entry] = true
-			list = append(list, entry)
-		}
+// findProjectDir finds the directory of the analyzed module relative to the current working directory
+func findProjectDir(moduleName string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	// Find the directory that contains the go.mod file for this module
+	if moduleDir := findModuleDir(cwd, moduleName); moduleDir != "" {
+		return moduleDir, nil
 	}
-	return list
+	return cwd, nil
 }
 
 // findModuleDir searches for a go.mod file that contains the specified module name
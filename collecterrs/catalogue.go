@@ -0,0 +1,156 @@
+package collecterrs
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Report is the analysis result: the catalogue of errors and the diagnostics of the analysis
+type Report struct {
+	Services    Catalogue                `json:"services"`
+	Diagnostics []Diagnostic             `json:"diagnostics,omitempty"`
+	Handled     []HandledError           `json:"handled,omitempty"` // Checks excluding errors from the usecases, see Explain
+	Graph       []GraphEdge              `json:"-"`                 // Written by WriteDOT and WriteMermaid
+	Protos      map[string]*ProtoService `json:"-"`                 // Proto services registered by the services, see ProtoFiles
+}
+
+// Catalogue is service → usecase → possible errors
+type Catalogue map[string]map[string][]ErrorEntry
+
+// Reasons of the provider calls dropped while linking
+const (
+	ReasonExternal      = "external"       // The provider is not a service of the project
+	ReasonUnknownMethod = "unknown method" // The service or the layer has no such usecase or method
+	ReasonNoErrors      = "no errors"      // The called usecase can not return named errors
+)
+
+// Diagnostic describes a provider call dropped while linking, its errors are not in the catalogue
+type Diagnostic struct {
+	Usecase      string `json:"usecase"`                // Calling usecase or layer method, ex: dummy.Cases
+	Call         string `json:"call"`                   // ex: [Redis].Get
+	Reason       string `json:"reason"`                 // ReasonExternal, ReasonUnknownMethod or ReasonNoErrors
+	Pos          string `json:"pos"`                    // Position of the call, relative to the module directory
+	Acknowledged bool   `json:"acknowledged,omitempty"` // The external provider is listed in the config externals
+
+	pos token.Position // Position for sorting
+}
+
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s calls %s: %s", d.Pos, d.Usecase, d.Call, d.Reason)
+}
+
+// Failed returns diagnostics that fail the run: unacknowledged external providers and unknown methods
+func (r *Report) Failed() []Diagnostic {
+	var failed []Diagnostic
+	for _, d := range r.Diagnostics {
+		if d.Reason != ReasonNoErrors && !d.Acknowledged {
+			failed = append(failed, d)
+		}
+	}
+	return failed
+}
+
+// ErrorEntry describes one possible error of a usecase
+type ErrorEntry struct {
+	Code        string        `json:"code"`               // Code as clients see it in ServerError.Code
+	Type        string        `json:"type"`               // errs.Type: USER_RELATED_ERROR or INTERNAL_ERROR
+	Description string        `json:"description"`        // Description from NewServiceError
+	Service     string        `json:"service"`            // Service that returns the error
+	Details     []DetailField `json:"details,omitempty"`  // Keys passed with WithDetails
+	Chain       []string      `json:"chain"`              // Usecases the error passes through, ex: users.ConfirmLogin → otp.ValidateCode
+	Declared    string        `json:"declared,omitempty"` // Position of NewServiceError, relative to the module directory
+	Var         string        `json:"var,omitempty"`      // Declared variable as pkgPath.Name, empty for inline declarations
+	Trace       []Site        `json:"trace,omitempty"`    // Sites from the usecase down to the return of the error, see Explain
+}
+
+// DetailField is a key of the details map passed with WithDetails
+type DetailField struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+func (e ErrorEntry) String() string {
+	code := e.Code
+	if len(e.Details) > 0 {
+		code = fmt.Sprintf("%s (%s)", code, detailsString(e.Details))
+	}
+	return fmt.Sprintf("%s.%s", e.Service, code)
+}
+
+func detailsString(details []DetailField) string {
+	params := make([]string, 0, len(details))
+	for _, d := range details {
+		params = append(params, fmt.Sprintf("%s:%s", d.Key, d.Type))
+	}
+	return strings.Join(params, ",")
+}
+
+// appendEntry adds the entry unless the same error of the same service is already in the list
+func appendEntry(entries []ErrorEntry, entry ErrorEntry) []ErrorEntry {
+	for _, e := range entries {
+		if e.Service == entry.Service && e.Code == entry.Code {
+			return entries
+		}
+	}
+	return append(entries, entry)
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	output, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(output, '\n'))
+	return err
+}
+
+// WriteText writes the report as a plain list, one usecase per block, then the diagnostics
+func (r *Report) WriteText(w io.Writer) error {
+	c := r.Services
+	for _, serviceName := range sortedKeys(c) {
+		for _, usecaseName := range sortedKeys(c[serviceName]) {
+			if _, err := fmt.Fprintf(w, "%s.%s\n", serviceName, usecaseName); err != nil {
+				return err
+			}
+			for _, e := range c[serviceName][usecaseName] {
+				line := fmt.Sprintf("  %s [%s] %s", e, e.Type, e.Description)
+				if len(e.Chain) > 1 {
+					line += " via " + strings.Join(e.Chain, " → ")
+				}
+				if _, err := fmt.Fprintln(w, line); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if len(r.Diagnostics) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "\nDiagnostics"); err != nil {
+		return err
+	}
+	for _, d := range r.Diagnostics {
+		line := "  " + d.String()
+		if d.Acknowledged {
+			line += " (acknowledged)"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+package collecterrs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes the layout of the analyzed project, usually loaded from collecterrs.yaml
+type Config struct {
+	ModuleDir   string   `yaml:"module"`       // Directory of the analyzed module, containing go.mod
+	Services    string   `yaml:"services"`     // Glob of service directories, relative to ModuleDir
+	ErrsPkg     string   `yaml:"errs"`         // Package declaring errs.ServiceError, relative to the module
+	Providers   string   `yaml:"providers"`    // Glob of packages declaring the Providers structs, relative to ModuleDir
+	Layers      []Layer  `yaml:"layers"`       // Layers of a service from the lowest one, the last layer contains usecases
+	Mode        string   `yaml:"mode"`         // Analysis mode: ModeAST or ModeSSA
+	Externals   []string `yaml:"externals"`    // Acknowledged external providers: Provider or Provider.Method
+	ProtoErrors string   `yaml:"proto_errors"` // Import path of errors.proto declaring the (errors.possible) method option
+}
+
+const (
+	ModeAST = "ast" // Walk the syntax tree of the function, ignoring control flow
+	ModeSSA = "ssa" // Follow error values in SSA form to the return instructions
+)
+
+// Layer is a layer of a service. Errors of a layer fold into the layers above it,
+// when they call the layer through the provider field
+type Layer struct {
+	Name     string `yaml:"name"`
+	Package  string `yaml:"package"`  // Package directory pattern, relative to the service directory
+	Provider string `yaml:"provider"` // Providers field through which the upper layers call this layer
+}
+
+// DefaultConfig returns the layout of the example project
+func DefaultConfig() Config {
+	return Config{
+		ModuleDir: "project",
+		Services:  "services/*",
+		ErrsPkg:   "pkg/errs",
+		Providers: "config/services/*",
+		Layers: []Layer{
+			{Name: "storage", Package: "storage", Provider: "Storage"},
+			{Name: "usecase", Package: "usecase"},
+		},
+		Mode:        ModeAST,
+		Externals:   []string{"Redis", "ProviderOtp"},
+		ProtoErrors: "specs/proto/errors/errors.proto",
+	}
+}
+
+// LoadConfig reads the config file, omitted fields are taken from DefaultConfig
+func LoadConfig(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Layers = nil
+	cfg.Externals = nil // externals of the example project are not acknowledged for others
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if len(cfg.Layers) == 0 {
+		cfg.Layers = DefaultConfig().Layers
+	}
+	return cfg, cfg.Validate()
+}
+
+// Validate checks the analysis mode and that the layers can be folded into each other
+func (c Config) Validate() error {
+	switch c.Mode {
+	case "", ModeAST, ModeSSA:
+	default:
+		return fmt.Errorf("unknown analysis mode %q", c.Mode)
+	}
+	if len(c.Layers) == 0 {
+		return fmt.Errorf("no layers configured")
+	}
+	providers := map[string]bool{}
+	for i, layer := range c.Layers {
+		if layer.Package == "" {
+			return fmt.Errorf("layer %q: package is not set", layer.Name)
+		}
+		if i == len(c.Layers)-1 {
+			break // usecase layer is not called by other layers
+		}
+		if layer.Provider == "" {
+			return fmt.Errorf("layer %q: provider is not set", layer.Name)
+		}
+		if providers[layer.Provider] {
+			return fmt.Errorf("layer %q: provider %s is used by another layer", layer.Name, layer.Provider)
+		}
+		providers[layer.Provider] = true
+	}
+	return nil
+}
+
+// isExternal checks that the provider call is acknowledged as a call outside of the project
+func (c Config) isExternal(call ProviderCall) bool {
+	for _, external := range c.Externals {
+		if external == call.Provider || external == call.Provider+"."+call.Method {
+			return true
+		}
+	}
+	return false
+}
+
+// UsecaseLayer returns the top layer, whose methods are usecases
+func (c Config) UsecaseLayer() Layer {
+	return c.Layers[len(c.Layers)-1]
+}
+
+// isLayerProvider checks that the provider field is a call of a lower layer
+func (c Config) isLayerProvider(provider string) bool {
+	for _, layer := range c.Layers[:len(c.Layers)-1] {
+		if layer.Provider == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrsImport returns the import path of the package declaring errs.ServiceError
+func (c Config) ErrsImport() (string, error) {
+	moduleName, err := readModuleName(c.ModuleDir)
+	if err != nil {
+		return "", err
+	}
+	return moduleName + "/" + strings.Trim(c.ErrsPkg, "/"), nil
+}
+
+// ImportPath returns the import path of the directory, empty if the directory is outside of the module
+func (c Config) ImportPath(dir string) (string, error) {
+	moduleName, err := readModuleName(c.ModuleDir)
+	if err != nil {
+		return "", err
+	}
+	moduleDir, err := filepath.Abs(c.ModuleDir)
+	if err != nil {
+		return "", err
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(moduleDir, dir)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", nil
+	}
+	if rel == "." {
+		return moduleName, nil
+	}
+	return moduleName + "/" + filepath.ToSlash(rel), nil
+}
+
+// readModuleName reads the module name from the go.mod file of the module directory
+func readModuleName(moduleDir string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(moduleDir, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod file: %w", err)
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module ")), nil
+		}
+	}
+
+	return "", fmt.Errorf("module declaration not found in go.mod file")
+}
@@ -0,0 +1,200 @@
+package collecterrs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TypeUserRelated is the errs.Type of errors clients have to handle
+const TypeUserRelated = "USER_RELATED_ERROR"
+
+// Kinds of changes of the errors of a usecase
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Severities of the changes for the clients
+const (
+	SeverityBreaking   = "breaking"   // Clients have to handle a new user related error or lose details
+	SeverityCompatible = "compatible" // Clients keep working as before
+)
+
+// Change is a change of one error of a usecase between two catalogues
+type Change struct {
+	Usecase  string `json:"usecase"`           // ex: users.Login
+	Error    string `json:"error"`             // Service and code, ex: otp.MaxAttemptsExceeded
+	Type     string `json:"type"`              // errs.Type of the error in the new catalogue, or in the old one if removed
+	Kind     string `json:"kind"`              // ChangeAdded, ChangeRemoved or ChangeChanged
+	Severity string `json:"severity"`          // SeverityBreaking or SeverityCompatible
+	Details  string `json:"details,omitempty"` // What is changed, ex: type INTERNAL_ERROR → USER_RELATED_ERROR
+}
+
+func (c Change) String() string {
+	mark := map[string]string{ChangeAdded: "+", ChangeRemoved: "-", ChangeChanged: "~"}[c.Kind]
+	line := fmt.Sprintf("%s %s [%s]", mark, c.Error, c.Type)
+	if c.Details != "" {
+		line += ": " + c.Details
+	}
+	if c.Severity == SeverityBreaking {
+		line += " (breaking)"
+	}
+	return line
+}
+
+// ReadReport reads the report written by WriteJSON.
+// Catalogues written before the report had sections are read as well
+func ReadReport(r io.Reader) (*Report, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(content, &sections); err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	report := &Report{}
+	if _, ok := sections["services"]; !ok {
+		err = json.Unmarshal(content, &report.Services)
+	} else {
+		err = json.Unmarshal(content, report)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse report: %w", err)
+	}
+	return report, nil
+}
+
+// DiffCatalogues compares errors of every usecase of the old and the new catalogues.
+// A new user related error is breaking, as well as an error becoming user related and removed details keys.
+// Errors of new usecases are compatible, there are no clients handling them yet
+func DiffCatalogues(old, new Catalogue) []Change {
+	changes := []Change{}
+	for _, serviceName := range sortedKeys(mergeKeys(old, new)) {
+		for _, usecaseName := range sortedKeys(mergeKeys(old[serviceName], new[serviceName])) {
+			usecase := serviceName + "." + usecaseName
+			oldEntries, existed := old[serviceName][usecaseName]
+			newEntries := new[serviceName][usecaseName]
+
+			oldByID := map[string]ErrorEntry{}
+			for _, e := range oldEntries {
+				oldByID[e.Service+"."+e.Code] = e
+			}
+			newIDs := map[string]bool{}
+			for _, e := range newEntries {
+				id := e.Service + "." + e.Code
+				newIDs[id] = true
+				o, ok := oldByID[id]
+				if !ok {
+					change := Change{Usecase: usecase, Error: id, Type: e.Type, Kind: ChangeAdded, Severity: SeverityCompatible}
+					if existed && e.Type == TypeUserRelated {
+						change.Severity = SeverityBreaking
+					}
+					changes = append(changes, change)
+					continue
+				}
+				if change, ok := diffEntry(usecase, id, o, e); ok {
+					changes = append(changes, change)
+				}
+			}
+			for _, e := range oldEntries {
+				if id := e.Service + "." + e.Code; !newIDs[id] {
+					changes = append(changes, Change{Usecase: usecase, Error: id, Type: e.Type, Kind: ChangeRemoved, Severity: SeverityCompatible})
+				}
+			}
+		}
+	}
+	return changes
+}
+
+// diffEntry compares the type, the description and the details keys of the error
+func diffEntry(usecase, id string, old, new ErrorEntry) (Change, bool) {
+	change := Change{Usecase: usecase, Error: id, Type: new.Type, Kind: ChangeChanged, Severity: SeverityCompatible}
+	var details []string
+	if old.Type != new.Type {
+		details = append(details, fmt.Sprintf("type %s → %s", old.Type, new.Type))
+		if new.Type == TypeUserRelated {
+			change.Severity = SeverityBreaking
+		}
+	}
+	if old.Description != new.Description {
+		details = append(details, fmt.Sprintf("description %q → %q", old.Description, new.Description))
+	}
+	oldKeys := map[string]bool{}
+	for _, d := range old.Details {
+		oldKeys[d.Key] = true
+	}
+	newKeys := map[string]bool{}
+	for _, d := range new.Details {
+		newKeys[d.Key] = true
+		if !oldKeys[d.Key] {
+			details = append(details, "details key "+d.Key+" added")
+		}
+	}
+	for _, d := range old.Details {
+		if !newKeys[d.Key] {
+			details = append(details, "details key "+d.Key+" removed")
+			change.Severity = SeverityBreaking
+		}
+	}
+	if len(details) == 0 {
+		return Change{}, false
+	}
+	change.Details = strings.Join(details, ", ")
+	return change, true
+}
+
+// WriteChanges writes the changes as a plain list, one usecase per block
+func WriteChanges(w io.Writer, changes []Change) error {
+	usecase := ""
+	for _, c := range changes {
+		if c.Usecase != usecase {
+			usecase = c.Usecase
+			if _, err := fmt.Fprintln(w, usecase); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintln(w, "  "+c.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteChangesJSON writes the changes as an indented JSON array, empty if nothing is changed
+func WriteChangesJSON(w io.Writer, changes []Change) error {
+	if changes == nil {
+		changes = []Change{}
+	}
+	output, err := json.MarshalIndent(changes, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(output, '\n'))
+	return err
+}
+
+// Breaking returns the breaking changes
+func Breaking(changes []Change) []Change {
+	var breaking []Change
+	for _, c := range changes {
+		if c.Severity == SeverityBreaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+func mergeKeys[V any](a, b map[string]V) map[string]bool {
+	keys := make(map[string]bool, len(a)+len(b))
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+	return keys
+}
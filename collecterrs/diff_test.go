@@ -0,0 +1,102 @@
+package collecterrs
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func entry(service, code, typ string, keys ...string) ErrorEntry {
+	e := ErrorEntry{Code: code, Type: typ, Description: code, Service: service}
+	for _, key := range keys {
+		e.Details = append(e.Details, DetailField{Key: key, Type: "string"})
+	}
+	return e
+}
+
+func TestDiffCatalogues(t *testing.T) {
+	before := Catalogue{
+		"users": {
+			"Login": {
+				entry("users", "UserBlocked", TypeUserRelated),
+				entry("otp", "MaxAttemptsExceeded", "INTERNAL_ERROR"),
+				entry("otp", "InvalidCode", TypeUserRelated, "max"),
+				entry("users", "Removed", TypeUserRelated),
+			},
+		},
+	}
+	after := Catalogue{
+		"users": {
+			"Login": {
+				entry("users", "UserBlocked", TypeUserRelated),
+				entry("otp", "MaxAttemptsExceeded", TypeUserRelated),
+				entry("otp", "InvalidCode", TypeUserRelated),
+				entry("users", "Internal", "INTERNAL_ERROR"),
+				entry("users", "NotFound", TypeUserRelated),
+			},
+			"Logout": {
+				entry("users", "UserBlocked", TypeUserRelated),
+			},
+		},
+	}
+
+	want := []Change{
+		{Usecase: "users.Login", Error: "otp.MaxAttemptsExceeded", Type: TypeUserRelated, Kind: ChangeChanged, Severity: SeverityBreaking,
+			Details: "type INTERNAL_ERROR → USER_RELATED_ERROR"},
+		{Usecase: "users.Login", Error: "otp.InvalidCode", Type: TypeUserRelated, Kind: ChangeChanged, Severity: SeverityBreaking,
+			Details: "details key max removed"},
+		{Usecase: "users.Login", Error: "users.Internal", Type: "INTERNAL_ERROR", Kind: ChangeAdded, Severity: SeverityCompatible},
+		{Usecase: "users.Login", Error: "users.NotFound", Type: TypeUserRelated, Kind: ChangeAdded, Severity: SeverityBreaking},
+		{Usecase: "users.Login", Error: "users.Removed", Type: TypeUserRelated, Kind: ChangeRemoved, Severity: SeverityCompatible},
+		{Usecase: "users.Logout", Error: "users.UserBlocked", Type: TypeUserRelated, Kind: ChangeAdded, Severity: SeverityCompatible},
+	}
+	changes := DiffCatalogues(before, after)
+	if len(changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %v", len(changes), len(want), changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("change %d:\n got %+v\nwant %+v", i, changes[i], want[i])
+		}
+	}
+	if breaking := Breaking(changes); len(breaking) != 3 {
+		t.Errorf("got %d breaking changes, want 3: %v", len(breaking), breaking)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteChangesJSON(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	var decoded []Change
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("invalid JSON %s: %v", buf.String(), err)
+	}
+	if len(decoded) != len(changes) || decoded[3] != changes[3] {
+		t.Errorf("JSON does not round trip: %s", buf.String())
+	}
+}
+
+func TestDiffCataloguesNoChanges(t *testing.T) {
+	c := Catalogue{"users": {"Login": {entry("users", "UserBlocked", TypeUserRelated)}}}
+	changes := DiffCatalogues(c, c)
+	if len(changes) != 0 {
+		t.Fatalf("got changes of the same catalogue: %v", changes)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteChangesJSON(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("got %q, want an empty JSON array", got)
+	}
+
+	buf.Reset()
+	if err := WriteChanges(&buf, changes); err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "" {
+		t.Errorf("got text output %q, want nothing", got)
+	}
+}
@@ -0,0 +1,213 @@
+package collecterrs
+
+import (
+	htmltemplate "html/template"
+	"io"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// Docs is the reference of the errors for people: every possible error of every usecase
+// and the reverse index of the usecases returning the error
+type Docs struct {
+	Services []docsService
+	Index    []docsIndexEntry
+}
+
+type docsService struct {
+	Name     string
+	Usecases []docsUsecase
+}
+
+type docsUsecase struct {
+	ID     string // ex: users.Login
+	Anchor string // Anchor of the Markdown heading
+	Errors []docsError
+}
+
+type docsError struct {
+	ErrorEntry
+	ID        string // ex: otp.InvalidCode
+	Keys      string // Details keys, ex: max
+	Via       string // Chain of the usecases, if the error comes from another usecase
+	SourceURL string // Link to the declaration, empty if the position is unknown
+	Source    string // file:line of the declaration
+}
+
+type docsIndexEntry struct {
+	ID          string
+	Type        string
+	Description string
+	Usecases    []docsUsecase // Without errors, only ID and Anchor
+}
+
+// NewDocs builds the reference of the catalogue. Links to the sources are sourceURL + file + #L<line>,
+// where the files are relative to the module directory: ../project/ or https://git.example.com/repo/blob/main/project/
+func NewDocs(c Catalogue, sourceURL string) *Docs {
+	if sourceURL != "" && !strings.HasSuffix(sourceURL, "/") {
+		sourceURL += "/"
+	}
+	d := &Docs{}
+	index := map[string]*docsIndexEntry{}
+	for _, serviceName := range sortedKeys(c) {
+		service := docsService{Name: serviceName}
+		for _, usecaseName := range sortedKeys(c[serviceName]) {
+			usecase := docsUsecase{ID: serviceName + "." + usecaseName}
+			usecase.Anchor = markdownAnchor(usecase.ID)
+			for _, e := range c[serviceName][usecaseName] {
+				de := docsError{ErrorEntry: e, ID: e.Service + "." + e.Code}
+				var keys []string
+				for _, detail := range e.Details {
+					keys = append(keys, detail.Key)
+				}
+				de.Keys = strings.Join(keys, ", ")
+				if len(e.Chain) > 1 {
+					de.Via = strings.Join(e.Chain, " → ")
+				}
+				if file, line, ok := splitPosition(e.Declared); ok {
+					de.Source = file + ":" + line
+					de.SourceURL = sourceURL + file + "#L" + line
+				}
+				usecase.Errors = append(usecase.Errors, de)
+
+				entry, ok := index[de.ID]
+				if !ok {
+					entry = &docsIndexEntry{ID: de.ID, Type: e.Type, Description: e.Description}
+					index[de.ID] = entry
+				}
+				entry.Usecases = append(entry.Usecases, docsUsecase{ID: usecase.ID, Anchor: usecase.Anchor})
+			}
+			service.Usecases = append(service.Usecases, usecase)
+		}
+		d.Services = append(d.Services, service)
+	}
+	for _, id := range sortedKeys(index) {
+		d.Index = append(d.Index, *index[id])
+	}
+	return d
+}
+
+// WriteMarkdown writes the reference as Markdown, the usecases are linked by the anchors of their headings
+func (d *Docs) WriteMarkdown(w io.Writer) error {
+	return markdownDocsTemplate.Execute(w, d)
+}
+
+// WriteHTML writes the reference as a standalone HTML page
+func (d *Docs) WriteHTML(w io.Writer) error {
+	return htmlDocsTemplate.Execute(w, d)
+}
+
+// splitPosition splits file:line:column into the file and the line
+func splitPosition(pos string) (string, string, bool) {
+	parts := strings.Split(pos, ":")
+	if len(parts) < 3 {
+		return "", "", false
+	}
+	return strings.Join(parts[:len(parts)-2], ":"), parts[len(parts)-2], true
+}
+
+// markdownAnchor returns the anchor Markdown renderers give to the heading: users.Login → userslogin
+func markdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
+
+// markdownCell escapes the text for a table cell
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+var markdownDocsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{"cell": markdownCell}).Parse(
+	`# Справочник ошибок
+
+{{range .Services}}- **{{.Name}}**:{{range $i, $u := .Usecases}}{{if $i}},{{end}} [{{.ID}}](#{{.Anchor}}){{end}}
+{{end}}- [Указатель ошибок](#указатель-ошибок)
+{{range .Services}}
+## {{.Name}}
+{{range .Usecases}}
+### {{.ID}}
+{{if .Errors}}
+| Код | Тип | Описание | Детали | Сервис | Объявление |
+|-----|-----|----------|--------|--------|------------|
+{{range .Errors}}| ` + "`{{.Code}}`" + ` | {{.Type}} | {{cell .Description}} | {{cell .Keys}} | {{.Service}}{{if .Via}} ({{.Via}}){{end}} | {{if .SourceURL}}[{{.Source}}]({{.SourceURL}}){{end}} |
+{{end}}{{else}}
+Именованных ошибок нет.
+{{end}}{{end}}{{end}}
+## Указатель ошибок
+
+| Ошибка | Тип | Описание | Юзкейсы |
+|--------|-----|----------|---------|
+{{range .Index}}| ` + "`{{.ID}}`" + ` | {{.Type}} | {{cell .Description}} | {{range $i, $u := .Usecases}}{{if $i}}, {{end}}[{{.ID}}](#{{.Anchor}}){{end}} |
+{{end}}`))
+
+var htmlDocsTemplate = htmltemplate.Must(htmltemplate.New("docs").Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>Справочник ошибок</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+code { font-size: 0.95em; }
+.USER_RELATED_ERROR { color: #b00020; }
+.via { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Справочник ошибок</h1>
+<ul>
+{{- range .Services}}
+<li><b>{{.Name}}</b>:{{range $i, $u := .Usecases}}{{if $i}},{{end}} <a href="#{{.ID}}">{{.ID}}</a>{{end}}</li>
+{{- end}}
+<li><a href="#index">Указатель ошибок</a></li>
+</ul>
+{{range .Services}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+{{- range .Usecases}}
+<h3 id="{{.ID}}">{{.ID}}</h3>
+{{- if .Errors}}
+<table>
+<tr><th>Код</th><th>Тип</th><th>Описание</th><th>Детали</th><th>Сервис</th><th>Объявление</th></tr>
+{{- range .Errors}}
+<tr>
+<td><a href="#error-{{.ID}}"><code>{{.Code}}</code></a></td>
+<td class="{{.Type}}">{{.Type}}</td>
+<td>{{.Description}}</td>
+<td>{{.Keys}}</td>
+<td>{{.Service}}{{if .Via}}<div class="via">{{.Via}}</div>{{end}}</td>
+<td>{{if .SourceURL}}<a href="{{.SourceURL}}">{{.Source}}</a>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+{{- else}}
+<p>Именованных ошибок нет.</p>
+{{- end}}
+{{- end}}
+{{end}}
+<h2 id="index">Указатель ошибок</h2>
+<table>
+<tr><th>Ошибка</th><th>Тип</th><th>Описание</th><th>Юзкейсы</th></tr>
+{{- range .Index}}
+<tr id="error-{{.ID}}">
+<td><code>{{.ID}}</code></td>
+<td class="{{.Type}}">{{.Type}}</td>
+<td>{{.Description}}</td>
+<td>{{range $i, $u := .Usecases}}{{if $i}}, {{end}}<a href="#{{.ID}}">{{.ID}}</a>{{end}}</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
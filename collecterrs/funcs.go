@@ -0,0 +1,112 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// FuncIndex holds declarations of the functions and methods of the analyzed module by their objects,
+// so that calls into other packages can be followed
+type FuncIndex struct {
+	decls map[*types.Func]FuncDecl
+	impls map[*types.Func]*types.Func // Interface method → method of the single implementation
+}
+
+// FuncDecl is a declaration of a function with the package it is declared in
+type FuncDecl struct {
+	Func *types.Func
+	Decl *ast.FuncDecl
+	Pkg  *packages.Package
+}
+
+func NewFuncIndex() *FuncIndex {
+	return &FuncIndex{
+		decls: make(map[*types.Func]FuncDecl),
+		impls: make(map[*types.Func]*types.Func),
+	}
+}
+
+// Collect indexes declarations of the package functions
+func (x *FuncIndex) Collect(pkg *packages.Package) {
+	if pkg.TypesInfo == nil {
+		return
+	}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			if obj, ok := pkg.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+				x.decls[obj] = FuncDecl{Func: obj, Decl: fn, Pkg: pkg}
+			}
+		}
+	}
+}
+
+// Lookup returns the declaration of the function, false if it is not declared in the module
+func (x *FuncIndex) Lookup(fn *types.Func) (FuncDecl, bool) {
+	d, ok := x.decls[fn.Origin()]
+	return d, ok
+}
+
+// Resolve returns the declaration of the called function or method. Methods are resolved by the receiver type,
+// including methods promoted from embedded structs. A method of an interface is resolved to the method
+// of the single type of the interface package implementing it:
+//
+//	type storageImpl struct {
+//		Storage // s.Storage.GetUser calls storageImpl.GetUser
+//	}
+func (x *FuncIndex) Resolve(call *ast.CallExpr, info *types.Info) (FuncDecl, bool) {
+	fn := calledFunc(call, info)
+	if fn == nil {
+		return FuncDecl{}, false
+	}
+	if impl := x.Implementation(fn); impl != nil {
+		fn = impl
+	}
+	return x.Lookup(fn)
+}
+
+// Implementation returns the method of the single concrete type of the interface package implementing the interface,
+// nil if the method is not an interface method or there are no or several implementations
+func (x *FuncIndex) Implementation(method *types.Func) *types.Func {
+	if impl, ok := x.impls[method]; ok {
+		return impl
+	}
+	recv := method.Type().(*types.Signature).Recv()
+	if recv == nil || !types.IsInterface(recv.Type()) || method.Pkg() == nil {
+		return nil
+	}
+	iface := recv.Type().Underlying().(*types.Interface)
+
+	var impl *types.Func
+	scope := method.Pkg().Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() || types.IsInterface(tn.Type()) {
+			continue
+		}
+		if named, ok := tn.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+			continue
+		}
+		ptr := types.NewPointer(tn.Type())
+		if !types.Implements(ptr, iface) {
+			continue
+		}
+		obj, _, _ := types.LookupFieldOrMethod(ptr, false, method.Pkg(), method.Name())
+		m, ok := obj.(*types.Func)
+		if !ok || types.IsInterface(m.Type().(*types.Signature).Recv().Type()) {
+			continue // implemented only by embedding the interface
+		}
+		if impl != nil {
+			impl = nil // several implementations, the called one is unknown
+			break
+		}
+		impl = m
+	}
+	x.impls[method] = impl
+	return impl
+}
@@ -0,0 +1,322 @@
+package collecterrs
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+// GoErrorsPackage describes the generated Go package of the errors of a service: specs/errors/<svc>.
+// The package declares the errors of the service itself as its declared variables,
+// errors of other services are referenced by their declared variables
+type GoErrorsPackage struct {
+	Service    string // Service of the project, ex: otp
+	Name       string // Package name, ex: otperrors
+	ErrsImport string // Import path of the package declaring errs.ServiceError
+	Imports    []goImport
+	Errors     []goError // Errors of the service
+	RPCs       []goRPC
+
+	genImport string            // Import path of the directory of the generated packages
+	aliases   map[string]string // Import path → package name in the generated file
+}
+
+type goImport struct {
+	Name string // Package name, empty if it is the last element of the path
+	Path string
+}
+
+type goError struct {
+	Ident       string // Exported name, ex: InvalidCode, OtpInvalidCode if another service has the same code
+	Ref         string // Expression of the error, ex: InvalidCode or errsOtp.InvalidCodeError
+	Source      string // Declared variable the error of the service is, ex: errsOtp.InvalidCodeError, empty for inline declarations
+	Code        string
+	Service     string
+	Type        string // errs.Type constant, ex: TypeUserRelatedError
+	Description string
+	Details     string // Details keys, ex: max, retryTime
+}
+
+type goRPC struct {
+	Name   string // ex: ValidateCode
+	Type   string // Unexported type of the helpers, ex: validateCodeErrors
+	Errors []goError
+}
+
+// NewGoErrorsPackage collects the errors of every usecase of the service for the generated package.
+// genImport is the import path of the directory of the generated packages, it is needed for the errors
+// of other services declared inline, which are declared only by the package of their service
+func NewGoErrorsPackage(c Catalogue, service, errsImport, genImport string) (*GoErrorsPackage, error) {
+	p := &GoErrorsPackage{
+		Service:    service,
+		Name:       goPackageName(service) + "errors",
+		ErrsImport: errsImport,
+		genImport:  genImport,
+		aliases:    map[string]string{},
+	}
+	p.importAs(errsImport, "errs")
+
+	declared := map[string]bool{}
+	for _, usecase := range sortedKeys(c[service]) {
+		rpc := goRPC{Name: usecase, Type: lowerFirst(goIdent(usecase)) + "Errors"}
+		services := map[string]map[string]bool{} // Code → services
+		for _, e := range c[service][usecase] {
+			if services[e.Code] == nil {
+				services[e.Code] = map[string]bool{}
+			}
+			services[e.Code][e.Service] = true
+		}
+		seen := map[string]bool{}
+		for _, e := range c[service][usecase] {
+			id := e.Service + "." + e.Code
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			ge, err := p.newGoError(e)
+			if err != nil {
+				return nil, err
+			}
+			if e.Service == service && !declared[id] {
+				declared[id] = true
+				p.Errors = append(p.Errors, ge)
+			}
+			if len(services[e.Code]) > 1 && e.Service != service {
+				ge.Ident = goIdent(e.Service) + ge.Ident
+			}
+			rpc.Errors = append(rpc.Errors, ge)
+		}
+		p.RPCs = append(p.RPCs, rpc)
+	}
+	return p, nil
+}
+
+// newGoError resolves the expression the package refers to the error by
+func (p *GoErrorsPackage) newGoError(e ErrorEntry) (goError, error) {
+	ge := goError{Ident: goIdent(e.Code), Code: e.Code, Service: e.Service, Description: e.Description, Type: "TypeInternalError"}
+	if e.Type == TypeUserRelated {
+		ge.Type = "TypeUserRelatedError"
+	}
+	var keys []string
+	for _, d := range e.Details {
+		keys = append(keys, d.Key)
+	}
+	ge.Details = strings.Join(keys, ", ")
+
+	if i := strings.LastIndex(e.Var, "."); i > 0 {
+		ge.Source = p.importAs(e.Var[:i], "") + "." + e.Var[i+1:]
+	}
+	switch {
+	case e.Service == p.Service:
+		ge.Ref = ge.Ident
+	case ge.Source != "":
+		ge.Ref = ge.Source
+	case p.genImport != "":
+		ge.Ref = p.importAs(p.genImport+"/"+goPackageName(e.Service), goPackageName(e.Service)+"errors") + "." + ge.Ident
+	default:
+		return goError{}, fmt.Errorf("%s: error %s.%s is declared inline and the generated package of %s can not be imported",
+			p.Service, e.Service, e.Code, e.Service)
+	}
+	return ge, nil
+}
+
+// importAs adds the import and returns the package name it is referred by in the generated file.
+// The name is the last element of the path if it is not set, with a number if another import has it
+func (p *GoErrorsPackage) importAs(path, name string) string {
+	if alias, ok := p.aliases[path]; ok {
+		return alias
+	}
+	base := path[strings.LastIndex(path, "/")+1:]
+	if name == "" {
+		name = base
+		if !token.IsIdentifier(name) {
+			name = goPackageName(base)
+		}
+	}
+	alias := name
+	for n := 2; p.aliasTaken(alias); n++ {
+		alias = fmt.Sprintf("%s%d", name, n)
+	}
+	p.aliases[path] = alias
+	imp := goImport{Path: path}
+	if alias != base {
+		imp.Name = alias
+	}
+	p.Imports = append(p.Imports, imp)
+	return alias
+}
+
+func (p *GoErrorsPackage) aliasTaken(alias string) bool {
+	if alias == "errors" || alias == "status" || alias == p.Name {
+		return true
+	}
+	for _, taken := range p.aliases {
+		if taken == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteGoErrors generates the package of the errors of every service into dir/<svc> and returns the written files.
+// genImport is the import path of dir, empty if it is not in the module
+func WriteGoErrors(c Catalogue, dir, errsImport, genImport string) ([]string, error) {
+	var written []string
+	for _, service := range sortedKeys(c) {
+		p, err := NewGoErrorsPackage(c, service, errsImport, genImport)
+		if err != nil {
+			return nil, err
+		}
+		src, err := p.Generate()
+		if err != nil {
+			return nil, err
+		}
+		pkgDir := filepath.Join(dir, goPackageName(service))
+		if err := os.MkdirAll(pkgDir, 0o755); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(pkgDir, "errors.go")
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			return nil, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// Generate returns the formatted source of the package
+func (p *GoErrorsPackage) Generate() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := goErrorsTemplate.Execute(&buf, p); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated package %s: %w", p.Name, err)
+	}
+	return src, nil
+}
+
+// goIdent makes an exported Go identifier of the code: UserBlocked, user_blocked → UserBlocked
+func goIdent(s string) string {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, part := range parts {
+		r := []rune(part)
+		b.WriteString(string(unicode.ToUpper(r[0])) + string(r[1:]))
+	}
+	ident := b.String()
+	if ident == "" || unicode.IsDigit([]rune(ident)[0]) {
+		ident = "E" + ident
+	}
+	return ident
+}
+
+func lowerFirst(s string) string {
+	r := []rune(s)
+	return string(unicode.ToLower(r[0])) + string(r[1:])
+}
+
+// goPackageName makes the package name of the service: lower case letters and digits
+func goPackageName(service string) string {
+	return strings.ToLower(strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, service))
+}
+
+var goErrorsTemplate = template.Must(template.New("errors").Parse(`// Code generated by collecterrs. DO NOT EDIT.
+
+// Package {{.Name}} описывает ошибки, которые могут вернуть методы сервиса {{.Service}}.
+package {{.Name}}
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/status"
+{{- range .Imports}}
+	{{if .Name}}{{.Name}} {{end}}"{{.Path}}"
+{{- end}}
+)
+{{if .Errors}}
+var (
+{{- range .Errors}}
+	// {{.Ident}}: {{.Description}}{{if .Details}}. Details: {{.Details}}{{end}}
+	{{.Ident}} = {{if .Source}}{{.Source}}{{else}}errs.NewServiceError({{printf "%q" .Code}}, errs.{{.Type}}, {{printf "%q" .Description}}){{end}}
+{{- end}}
+)
+{{end}}
+{{- range $rpc := .RPCs}}
+// {{.Name}}Errors are the errors {{.Name}} can return
+var {{.Name}}Errors {{.Type}}
+
+type {{.Type}} struct{}
+{{range .Errors}}
+// Is{{.Ident}} checks that {{$rpc.Name}} returned {{.Service}}.{{.Code}}: {{.Description}}
+func ({{$rpc.Type}}) Is{{.Ident}}(err error) bool {
+	return {{.Ref}}.Is(err)
+}
+{{end}}
+// All returns the errors {{.Name}} can return
+func ({{.Type}}) All() []errs.ServiceError {
+	return []errs.ServiceError{ {{- range $i, $e := .Errors}}{{if $i}}, {{end}}{{.Ref}}{{end -}} }
+}
+
+// Known checks that err is one of the errors {{.Name}} can return
+func (e {{.Type}}) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error {{.Name}} returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func ({{.Type}}) Switch(err error,
+{{- range .Errors}}
+	on{{.Ident}} func(errs.ServiceError) error,
+{{- end}}
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+{{- if .Errors}}
+	se := serviceError(err)
+	switch {
+{{- range .Errors}}
+	case {{.Ref}}.Equals(se):
+		return on{{.Ident}}(se)
+{{- end}}
+	}
+{{- end}}
+	return other(err)
+}
+{{end}}
+// serviceError returns the ServiceError returned by the service itself or received over gRPC
+func serviceError(err error) errs.ServiceError {
+	var se errs.ServiceError
+	if errors.As(err, &se) {
+		return se
+	}
+	var sePtr *errs.ServiceError
+	if errors.As(err, &sePtr) && sePtr != nil {
+		return *sePtr
+	}
+	return errs.BuildFromGRPCStatus(status.Convert(err))
+}
+`))
@@ -0,0 +1,71 @@
+package collecterrs
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestNewGoErrorsPackage(t *testing.T) {
+	const module = "example.com/project"
+	c := Catalogue{
+		"users": {
+			"Login": {
+				{Code: "UserBlocked", Type: TypeUserRelated, Service: "users", Var: module + "/errs/errsUsers.UserBlockedError"},
+				{Code: "InvalidCode", Type: TypeUserRelated, Service: "users", Var: module + "/errs/errsUsers.InvalidCodeError"},
+				{Code: "InvalidCode", Type: TypeUserRelated, Service: "otp", Var: module + "/errs/errsOtp.InvalidCodeError"},
+				{Code: "InvalidCode", Type: TypeUserRelated, Service: "otp", Var: module + "/errs/errsOtp.InvalidCodeError"},
+				{Code: "Inline", Service: "otp"},
+			},
+			"Logout": {
+				{Code: "UserBlocked", Type: TypeUserRelated, Service: "users", Var: module + "/errs/errsUsers.UserBlockedError"},
+			},
+		},
+	}
+	p, err := NewGoErrorsPackage(c, "users", module+"/pkg/errs", module+"/specs/errors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "userserrors" {
+		t.Errorf("got package %s, want userserrors", p.Name)
+	}
+
+	var declared []string
+	for _, e := range p.Errors {
+		declared = append(declared, e.Ident+" = "+e.Source)
+	}
+	if got, want := strings.Join(declared, "; "), "UserBlocked = errsUsers.UserBlockedError; InvalidCode = errsUsers.InvalidCodeError"; got != want {
+		t.Errorf("got declared errors %q, want %q", got, want)
+	}
+
+	var login []string
+	for _, e := range p.RPCs[0].Errors {
+		login = append(login, e.Ident+" = "+e.Ref)
+	}
+	want := "UserBlocked = UserBlocked; InvalidCode = InvalidCode; OtpInvalidCode = errsOtp.InvalidCodeError; Inline = otperrors.Inline"
+	if got := strings.Join(login, "; "); got != want {
+		t.Errorf("got Login errors %q, want %q", got, want)
+	}
+
+	src, err := p.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "errors.go", src, parser.ImportsOnly)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var imports []string
+	for _, imp := range f.Imports {
+		imports = append(imports, imp.Path.Value)
+	}
+	if got := strings.Join(imports, " "); !strings.Contains(got, `"example.com/project/specs/errors/otp"`) ||
+		!strings.Contains(got, `"example.com/project/errs/errsOtp"`) {
+		t.Errorf("got imports %s", got)
+	}
+
+	if _, err := NewGoErrorsPackage(c, "users", module+"/pkg/errs", ""); err == nil {
+		t.Error("inline error of another service is referenced without its generated package")
+	}
+}
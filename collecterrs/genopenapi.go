@@ -0,0 +1,122 @@
+package collecterrs
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// WriteOpenAPI writes the OpenAPI components of the errors: a schema of the errs.ServerError JSON of every error,
+// a schema of the errors of every usecase discriminated by the code, and a response referencing it.
+// The responses are added to the operations of the API by $ref: '#/components/responses/UsersLoginError'.
+// Schemas of the errors are named Service_Code, so they do not collide with the schemas of the usecases
+func WriteOpenAPI(w io.Writer, c Catalogue) error {
+	schemas := map[string]any{
+		"ServerError": serverErrorSchema("Ответ сервиса с ошибкой, errs.ServerError", nil, nil),
+		UnnamedErrorCode: serverErrorSchema("Неименованная ошибка, ее показывают как общую ошибку сервиса",
+			[]string{UnnamedErrorCode}, nil),
+	}
+	responses := map[string]any{}
+	owners := map[string]string{} // Schema name → what it describes, to fail on names of different things
+	schemaName := func(name, owner string) (string, error) {
+		if existing, ok := owners[name]; ok && existing != owner {
+			return "", fmt.Errorf("OpenAPI schema %s describes both %s and %s", name, existing, owner)
+		}
+		if _, ok := schemas[name]; ok && owners[name] == "" {
+			return "", fmt.Errorf("OpenAPI schema %s of %s is reserved", name, owner)
+		}
+		owners[name] = owner
+		return name, nil
+	}
+	for _, service := range sortedKeys(c) {
+		for _, usecase := range sortedKeys(c[service]) {
+			name, err := schemaName(goIdent(service)+goIdent(usecase)+"Error", "usecase "+service+"."+usecase)
+			if err != nil {
+				return err
+			}
+			var oneOf []any
+			mapping := map[string]string{}
+			for _, e := range uniqueCodes(c[service][usecase]) {
+				errorName, err := schemaName(goIdent(e.Service)+"_"+goIdent(e.Code), "error "+e.Service+"."+e.Code)
+				if err != nil {
+					return err
+				}
+				if _, ok := schemas[errorName]; !ok {
+					schema := serverErrorSchema(e.Description, []string{e.Code}, e.Details)
+					schema["x-error-type"] = e.Type
+					schema["x-service"] = e.Service
+					schemas[errorName] = schema
+				}
+				oneOf = append(oneOf, schemaRef(errorName))
+				mapping[e.Code] = "#/components/schemas/" + errorName
+			}
+			oneOf = append(oneOf, schemaRef(UnnamedErrorCode))
+			mapping[UnnamedErrorCode] = "#/components/schemas/" + UnnamedErrorCode
+
+			schemas[name] = map[string]any{
+				"description":   "Ошибки " + service + "." + usecase,
+				"oneOf":         oneOf,
+				"discriminator": map[string]any{"propertyName": "code", "mapping": mapping},
+			}
+			responses[name] = map[string]any{
+				"description": "Ошибки " + service + "." + usecase,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaRef(name)},
+				},
+			}
+		}
+	}
+
+	// the struct keeps the order of the sections, maps are written with sorted keys
+	doc := struct {
+		OpenAPI    string         `yaml:"openapi"`
+		Info       map[string]any `yaml:"info"`
+		Paths      map[string]any `yaml:"paths"`
+		Components map[string]any `yaml:"components"`
+	}{
+		OpenAPI:    "3.0.3",
+		Info:       map[string]any{"title": "Ошибки сервисов", "version": "1.0.0"},
+		Paths:      map[string]any{},
+		Components: map[string]any{"schemas": schemas, "responses": responses},
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// serverErrorSchema describes the JSON of errs.ServerError with the possible codes and details keys
+func serverErrorSchema(description string, codes []string, details []DetailField) map[string]any {
+	code := map[string]any{"type": "string"}
+	if len(codes) > 0 {
+		code["enum"] = codes
+	}
+	detailsSchema := map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"type": "string"},
+	}
+	if len(details) > 0 {
+		properties := map[string]any{}
+		for _, d := range details {
+			properties[d.Key] = map[string]any{"type": "string"}
+		}
+		detailsSchema["properties"] = properties
+	}
+	return map[string]any{
+		"description": description,
+		"type":        "object",
+		"required":    []string{"code", "message"},
+		"properties": map[string]any{
+			"code":    code,
+			"message": map[string]any{"type": "string"},
+			"details": detailsSchema,
+		},
+	}
+}
+
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
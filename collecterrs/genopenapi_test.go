@@ -0,0 +1,41 @@
+package collecterrs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestWriteOpenAPISchemaNames(t *testing.T) {
+	// the error users.LoginError used to be named as the schema of the errors of users.Login
+	c := Catalogue{"users": {"Login": {{Code: "LoginError", Type: TypeUserRelated, Service: "users"}}}}
+	var buf bytes.Buffer
+	if err := WriteOpenAPI(&buf, c); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components struct {
+			Schemas map[string]struct {
+				OneOf []map[string]string `yaml:"oneOf"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}
+	if err := yaml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	usecase, ok := doc.Components.Schemas["UsersLoginError"]
+	if !ok || len(usecase.OneOf) != 2 || usecase.OneOf[0]["$ref"] != "#/components/schemas/Users_LoginError" {
+		t.Errorf("got usecase schema %v", usecase)
+	}
+	if _, ok := doc.Components.Schemas["Users_LoginError"]; !ok {
+		t.Error("schema of the error is not written")
+	}
+
+	c = Catalogue{"users": {"Login": {{Code: "user_blocked", Service: "users"}, {Code: "UserBlocked", Service: "users"}}}}
+	err := WriteOpenAPI(&bytes.Buffer{}, c)
+	if err == nil || !strings.Contains(err.Error(), "Users_UserBlocked") {
+		t.Errorf("got %v, want an error of the schema describing both errors", err)
+	}
+}
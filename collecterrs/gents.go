@@ -0,0 +1,111 @@
+package collecterrs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// UnnamedErrorCode is the code errs.BuildFromServiceError gives to unnamed errors, every usecase can return it
+const UnnamedErrorCode = "InternalServiceError"
+
+// WriteTypeScript writes the TypeScript module of the errors of every usecase:
+// the union of the codes, the errs.ServerError JSON of every error with its details keys and the descriptions
+func WriteTypeScript(w io.Writer, c Catalogue) error {
+	var b strings.Builder
+	b.WriteString(`// Code generated by collecterrs. DO NOT EDIT.
+
+/** Тип ошибки: USER_RELATED_ERROR нужно обязательно обработать на фронте */
+export type ErrorType = "USER_RELATED_ERROR" | "INTERNAL_ERROR";
+
+/** Ответ сервиса с ошибкой, errs.ServerError */
+export interface ServerError<Code extends string = string, Details = Record<string, string>> {
+  code: Code;
+  message: string;
+  details?: Details;
+}
+
+/** Описание именованной ошибки */
+export interface ErrorInfo {
+  type: ErrorType;
+  description: string;
+  service: string;
+  details: string[];
+}
+
+/** Код неименованной ошибки, ее показывают как общую ошибку сервиса */
+export const InternalServiceError = "InternalServiceError";
+`)
+	for _, service := range sortedKeys(c) {
+		for _, usecase := range sortedKeys(c[service]) {
+			name := goIdent(service) + goIdent(usecase)
+			entries := uniqueCodes(c[service][usecase])
+
+			fmt.Fprintf(&b, "\n/** Коды ошибок %s.%s */\n", service, usecase)
+			var codes []string
+			for _, e := range entries {
+				codes = append(codes, jsString(e.Code))
+			}
+			if len(codes) == 0 {
+				codes = []string{"never"}
+			}
+			fmt.Fprintf(&b, "export type %sErrorCode = %s;\n", name, strings.Join(codes, " | "))
+
+			fmt.Fprintf(&b, "\n/** Ошибки %s.%s */\nexport type %sError =\n", service, usecase, name)
+			for _, e := range entries {
+				fmt.Fprintf(&b, "  | ServerError<%s%s>\n", jsString(e.Code), tsDetails(e.Details))
+			}
+			fmt.Fprintf(&b, "  | ServerError<typeof InternalServiceError>;\n")
+
+			fmt.Fprintf(&b, "\nexport const %sErrors: Record<%sErrorCode, ErrorInfo> = {\n", lowerFirst(name), name)
+			for _, e := range entries {
+				var keys []string
+				for _, d := range e.Details {
+					keys = append(keys, jsString(d.Key))
+				}
+				fmt.Fprintf(&b, "  %s: { type: %s, description: %s, service: %s, details: [%s] },\n",
+					jsString(e.Code), jsString(e.Type), jsString(e.Description), jsString(e.Service), strings.Join(keys, ", "))
+			}
+			b.WriteString("};\n")
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// tsDetails returns the details type parameter of the error with details keys
+func tsDetails(details []DetailField) string {
+	if len(details) == 0 {
+		return ""
+	}
+	var fields []string
+	for _, d := range details {
+		fields = append(fields, jsString(d.Key)+": string")
+	}
+	return ", { " + strings.Join(fields, "; ") + " }"
+}
+
+// uniqueCodes returns the errors with distinct codes, clients tell the errors apart only by the code
+func uniqueCodes(entries []ErrorEntry) []ErrorEntry {
+	var unique []ErrorEntry
+	seen := map[string]bool{}
+	for _, e := range entries {
+		if !seen[e.Code] {
+			seen[e.Code] = true
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
+
+// jsString quotes the string for JavaScript, non-ASCII characters are kept as is
+func jsString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return `""`
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,91 @@
+package collecterrs
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// GraphEdge is a call of a usecase of another service through a provider
+type GraphEdge struct {
+	From       string   // Calling usecase, ex: users.Login
+	To         string   // Called usecase, ex: otp.GenerateCode
+	Propagated []string // Errors of the called usecase passed to the caller, ex: otp.MaxAttemptsExceeded
+	Handled    []string // Errors of the called usecase handled after the call
+}
+
+// WriteDOT writes the call graph of the usecases in Graphviz DOT, usecases are grouped by service
+func (r *Report) WriteDOT(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("digraph services {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for i, serviceName := range sortedKeys(r.Services) {
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "    label=%q;\n", serviceName)
+		for _, usecaseName := range sortedKeys(r.Services[serviceName]) {
+			fmt.Fprintf(&b, "    %q [label=%q];\n", serviceName+"."+usecaseName, usecaseName)
+		}
+		b.WriteString("  }\n")
+	}
+	for _, e := range r.Graph {
+		attrs := fmt.Sprintf("label=%q", edgeLabel(e, "\n"))
+		if len(e.Propagated) == 0 {
+			attrs += ", style=dashed"
+		}
+		fmt.Fprintf(&b, "  %q -> %q [%s];\n", e.From, e.To, attrs)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMermaid writes the call graph of the usecases as a Mermaid flowchart, usecases are grouped by service
+func (r *Report) WriteMermaid(w io.Writer) error {
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, serviceName := range sortedKeys(r.Services) {
+		fmt.Fprintf(&b, "  subgraph %s\n", serviceName)
+		for _, usecaseName := range sortedKeys(r.Services[serviceName]) {
+			fmt.Fprintf(&b, "    %s[\"%s\"]\n", mermaidID(serviceName+"."+usecaseName), usecaseName)
+		}
+		b.WriteString("  end\n")
+	}
+	for _, e := range r.Graph {
+		arrow := "-->"
+		if len(e.Propagated) == 0 {
+			arrow = "-.->"
+		}
+		label := strings.ReplaceAll(edgeLabel(e, "<br/>"), `"`, "#quot;")
+		if label == "" {
+			fmt.Fprintf(&b, "  %s %s %s\n", mermaidID(e.From), arrow, mermaidID(e.To))
+			continue
+		}
+		fmt.Fprintf(&b, "  %s %s|\"%s\"| %s\n", mermaidID(e.From), arrow, label, mermaidID(e.To))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// edgeLabel lists propagated errors, then handled ones
+func edgeLabel(e GraphEdge, sep string) string {
+	lines := append([]string{}, e.Propagated...)
+	if len(e.Handled) > 0 {
+		lines = append(lines, "handled: "+strings.Join(e.Handled, ", "))
+	}
+	return strings.Join(lines, sep)
+}
+
+// mermaidID makes a node id of the usecase, Mermaid ids can not contain dots
+func mermaidID(usecase string) string {
+	return strings.ReplaceAll(usecase, ".", "_")
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
@@ -0,0 +1,217 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// declaredImplementations returns the types of the package declared as implementations of interfaces:
+//
+//	var _ Users = (*useCasesImpl)(nil)
+//	func New(cfg *users.Config, providers *users.Providers) Users { ...; return useCases }
+func declaredImplementations(pkg *packages.Package) map[*types.TypeName][]*types.TypeName {
+	impls := map[*types.TypeName][]*types.TypeName{}
+	add := func(ifaceType types.Type, value ast.Expr) {
+		iface := typeName(ifaceType)
+		if iface == nil || !types.IsInterface(iface.Type()) {
+			return
+		}
+		valueType := pkg.TypesInfo.TypeOf(value)
+		if valueType == nil || types.IsInterface(valueType) {
+			return
+		}
+		impl := typeName(valueType)
+		if impl == nil || impl.Pkg() != pkg.Types {
+			return
+		}
+		for _, existing := range impls[iface] {
+			if existing == impl {
+				return
+			}
+		}
+		impls[iface] = append(impls[iface], impl)
+	}
+
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if vs.Type == nil {
+						continue
+					}
+					for _, value := range vs.Values {
+						add(pkg.TypesInfo.TypeOf(vs.Type), value)
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Recv != nil || decl.Body == nil || !decl.Name.IsExported() {
+					continue
+				}
+				sig, ok := pkg.TypesInfo.Defs[decl.Name].Type().(*types.Signature)
+				if !ok {
+					continue
+				}
+				results := sig.Results()
+				ast.Inspect(decl.Body, func(n ast.Node) bool {
+					switch n := n.(type) {
+					case *ast.FuncLit:
+						return false // returns of the closure
+					case *ast.ReturnStmt:
+						if len(n.Results) != results.Len() {
+							return true
+						}
+						for i, result := range n.Results {
+							add(results.At(i).Type(), result)
+						}
+					}
+					return true
+				})
+			}
+		}
+	}
+	return impls
+}
+
+// implements checks that the type or the pointer to it implements the interface
+func implements(impl, iface *types.TypeName) bool {
+	it, ok := iface.Type().Underlying().(*types.Interface)
+	if !ok {
+		return false
+	}
+	return types.Implements(types.NewPointer(impl.Type()), it)
+}
+
+// methodDecls indexes the methods declared in the package by their objects
+func methodDecls(pkg *packages.Package) map[*types.Func]*ast.FuncDecl {
+	decls := map[*types.Func]*ast.FuncDecl{}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil || fn.Body == nil {
+				continue
+			}
+			if obj, ok := pkg.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+				decls[obj] = fn
+			}
+		}
+	}
+	return decls
+}
+
+// resolveMethod returns the method the type calls by the name: declared on the type or promoted from an embedded field.
+// A method promoted from an embedded interface has no implementation, abstract is true for it
+func resolveMethod(impl *types.TypeName, name string) (fn *types.Func, abstract bool) {
+	sel := types.NewMethodSet(types.NewPointer(impl.Type())).Lookup(impl.Pkg(), name)
+	if sel == nil {
+		return nil, false
+	}
+	fn, ok := sel.Obj().(*types.Func)
+	if !ok {
+		return nil, false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	return fn, recv != nil && types.IsInterface(recv.Type())
+}
+
+// declaredMethods returns the exported methods declared on the type itself, sorted by name
+func declaredMethods(impl *types.TypeName) []*types.Func {
+	named, ok := types.Unalias(impl.Type()).(*types.Named)
+	if !ok {
+		return nil
+	}
+	var methods []*types.Func
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Exported() {
+			methods = append(methods, m)
+		}
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+	return methods
+}
+
+// pickImplementation returns the only candidate type, preferring the types declared as implementations.
+// Several candidates are reported, the first one is used
+func (ua *UsecaseAnalysis) pickImplementation(pkgPath, what string, candidates []*types.TypeName, isDeclared map[*types.TypeName]bool) *types.TypeName {
+	if len(candidates) > 1 {
+		var preferred []*types.TypeName
+		for _, tn := range candidates {
+			if isDeclared[tn] {
+				preferred = append(preferred, tn)
+			}
+		}
+		if len(preferred) > 0 {
+			candidates = preferred
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return nil
+	case 1:
+	default:
+		var names []string
+		for _, tn := range candidates {
+			names = append(names, tn.Name())
+		}
+		ua.warnf("%s: several types implement %s (%s), %s is analyzed", pkgPath, what, strings.Join(names, ", "), names[0])
+	}
+	return candidates[0]
+}
+
+// layerMethods returns the methods of the layer package the upper layers call through the provider field of the layer:
+// the methods of the field type → their declarations. An interface field is resolved through the method set
+// of the type of the package implementing it, so methods of other types with the same names are not the layer
+func (ua *UsecaseAnalysis) layerMethods(pkg *packages.Package, layer Layer) map[*types.Func]*ast.FuncDecl {
+	decls := methodDecls(pkg)
+	isDeclared := map[*types.TypeName]bool{}
+	for _, impls := range declaredImplementations(pkg) {
+		for _, impl := range impls {
+			isDeclared[impl] = true
+		}
+	}
+
+	called := map[*types.Func]*ast.FuncDecl{}
+	for _, field := range ua.providers.Types(layer.Provider) {
+		impl := field
+		fieldType := field.Type()
+		switch {
+		case types.IsInterface(fieldType):
+			var candidates []*types.TypeName
+			scope := pkg.Types.Scope()
+			for _, name := range scope.Names() {
+				tn, ok := scope.Lookup(name).(*types.TypeName)
+				if ok && !tn.IsAlias() && !types.IsInterface(tn.Type()) && implements(tn, field) {
+					candidates = append(candidates, tn)
+				}
+			}
+			if impl = ua.pickImplementation(pkg.PkgPath, field.Name(), candidates, isDeclared); impl == nil {
+				continue
+			}
+		case field.Pkg() != pkg.Types:
+			continue // the layer is declared in another package
+		default:
+			fieldType = types.NewPointer(fieldType)
+		}
+
+		methods := types.NewMethodSet(fieldType)
+		for i := 0; i < methods.Len(); i++ {
+			method := methods.At(i).Obj().(*types.Func)
+			fn, abstract := resolveMethod(impl, method.Name())
+			if fn == nil || abstract || decls[fn] == nil {
+				ua.warnf("%s: method %s of %s is not implemented by %s", pkg.PkgPath, method.Name(), field.Name(), impl.Name())
+				continue
+			}
+			called[method.Origin()] = decls[fn]
+		}
+	}
+	return called
+}
@@ -0,0 +1,246 @@
+package collecterrs
+
+import "strings"
+
+// LinkProviderErrors builds the catalogue: errors of the called services are inserted into the calling usecases
+func (ua *UsecaseAnalysis) LinkProviderErrors(errs map[string]map[string]*FuncErrors, verbose bool) Catalogue {
+	l := &linker{
+		ua:        ua,
+		errs:      errs,
+		verbose:   verbose,
+		catalogue: Catalogue{},
+		edges:     map[usecaseRef][]linkEdge{},
+		index:     map[usecaseRef]int{},
+		low:       map[usecaseRef]int{},
+		onStack:   map[usecaseRef]bool{},
+	}
+	for _, serviceName := range sortedKeys(errs) {
+		for _, usecaseName := range sortedKeys(errs[serviceName]) {
+			if ref := (usecaseRef{serviceName, usecaseName}); l.index[ref] == 0 {
+				l.visit(ref)
+			}
+		}
+	}
+
+	// calls of usecases, which can not return errors, are known only when everything is linked
+	for _, serviceName := range sortedKeys(errs) {
+		for _, usecaseName := range sortedKeys(errs[serviceName]) {
+			ref := usecaseRef{serviceName, usecaseName}
+			for _, edge := range l.edges[ref] {
+				if len(l.catalogue[edge.to.service][edge.to.usecase]) == 0 {
+					ua.drop(ref.String(), edge.call, ReasonNoErrors)
+				}
+				ua.graph = l.addGraphEdge(ua.graph, ref, edge)
+			}
+		}
+	}
+	return l.catalogue
+}
+
+// addGraphEdge adds the call to the graph, calls between the same usecases are merged
+func (l *linker) addGraphEdge(graph []GraphEdge, from usecaseRef, edge linkEdge) []GraphEdge {
+	i := 0
+	for i < len(graph) && (graph[i].From != from.String() || graph[i].To != edge.to.String()) {
+		i++
+	}
+	if i == len(graph) {
+		graph = append(graph, GraphEdge{From: from.String(), To: edge.to.String()})
+	}
+	for _, nested := range l.catalogue[edge.to.service][edge.to.usecase] {
+		code := nested.Service + "." + nested.Code
+		if edge.call.Handled.Has(nested.Code) {
+			graph[i].Handled = appendUnique(graph[i].Handled, code)
+		} else {
+			graph[i].Propagated = appendUnique(graph[i].Propagated, code)
+		}
+	}
+	return graph
+}
+
+// usecaseRef is a node of the graph of usecases calling each other through providers
+type usecaseRef struct {
+	service, usecase string
+}
+
+func (r usecaseRef) String() string {
+	return r.service + "." + r.usecase
+}
+
+// linkEdge is a provider call of a usecase of the project
+type linkEdge struct {
+	call ProviderCall
+	to   usecaseRef
+}
+
+// linker traverses the graph of usecases by strongly connected components (Tarjan).
+// Components are linked after all usecases they call, so every usecase is linked once,
+// and usecases calling each other in a cycle are linked again until their errors stop growing
+type linker struct {
+	ua        *UsecaseAnalysis
+	errs      map[string]map[string]*FuncErrors
+	verbose   bool
+	catalogue Catalogue
+	edges     map[usecaseRef][]linkEdge
+
+	index   map[usecaseRef]int // Visit order from 1, 0 for not visited usecases
+	low     map[usecaseRef]int
+	onStack map[usecaseRef]bool
+	stack   []usecaseRef
+	next    int
+}
+
+func (l *linker) visit(ref usecaseRef) {
+	l.next++
+	l.index[ref] = l.next
+	l.low[ref] = l.next
+	l.stack = append(l.stack, ref)
+	l.onStack[ref] = true
+
+	l.edges[ref] = l.resolveCalls(ref)
+	for _, edge := range l.edges[ref] {
+		switch {
+		case l.index[edge.to] == 0:
+			l.visit(edge.to)
+			l.low[ref] = min(l.low[ref], l.low[edge.to])
+		case l.onStack[edge.to]:
+			l.low[ref] = min(l.low[ref], l.index[edge.to])
+		}
+	}
+	if l.low[ref] != l.index[ref] {
+		return // the root of the component links it
+	}
+
+	var component []usecaseRef
+	for {
+		m := l.stack[len(l.stack)-1]
+		l.stack = l.stack[:len(l.stack)-1]
+		l.onStack[m] = false
+		component = append(component, m)
+		if m == ref {
+			break
+		}
+	}
+	// the root is visited first, link members in the visit order
+	for i, j := 0, len(component)-1; i < j; i, j = i+1, j-1 {
+		component[i], component[j] = component[j], component[i]
+	}
+	l.linkComponent(component)
+}
+
+// resolveCalls returns provider calls of the usecase to the usecases of the project,
+// other calls are dropped with diagnostics
+func (l *linker) resolveCalls(ref usecaseRef) []linkEdge {
+	var edges []linkEdge
+	for _, call := range l.errs[ref.service][ref.usecase].Calls {
+		nestedServiceName := l.ua.targetService(call)
+		s, ok := l.errs[nestedServiceName]
+		if !ok { // external provider, not our service - remove it, it definitely won't return named errors
+			if l.verbose {
+				debugf("Remove external provider %s from %s", call, ref)
+			}
+			l.ua.drop(ref.String(), call, ReasonExternal)
+			continue
+		}
+		if _, ok := s[call.Method]; !ok {
+			if l.verbose {
+				debugf("Remove service provider %s from %s [unknown method]", call, ref)
+			}
+			l.ua.drop(ref.String(), call, ReasonUnknownMethod)
+			continue
+		}
+		edges = append(edges, linkEdge{call: call, to: usecaseRef{nestedServiceName, call.Method}})
+	}
+	return edges
+}
+
+// linkComponent links usecases of the component, all usecases they call outside of it are linked already
+func (l *linker) linkComponent(component []usecaseRef) {
+	members := map[usecaseRef]bool{}
+	for _, m := range component {
+		members[m] = true
+	}
+	cyclic := len(component) > 1
+	for _, edge := range l.edges[component[0]] {
+		cyclic = cyclic || edge.to == component[0]
+	}
+	if cyclic {
+		l.ua.warnf("usecases call each other in a cycle: %s", l.cyclePath(component[0], members))
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, m := range component {
+			entries := l.linkUsecase(m)
+			if len(entries) != len(l.catalogue[m.service][m.usecase]) {
+				changed = true
+			}
+			if l.catalogue[m.service] == nil {
+				l.catalogue[m.service] = map[string][]ErrorEntry{}
+			}
+			l.catalogue[m.service][m.usecase] = entries
+		}
+		changed = changed && cyclic
+	}
+}
+
+// linkUsecase resolves errors of the usecase, inserting errors found so far in the called usecases
+func (l *linker) linkUsecase(ref usecaseRef) []ErrorEntry {
+	usecaseID := ref.String()
+	fe := l.errs[ref.service][ref.usecase]
+	usecase := Site{Kind: SiteUsecase, Name: usecaseID, pos: fe.Pos}
+	entries := []ErrorEntry{}
+	for _, e := range fe.Errors {
+		entry := l.ua.newErrorEntry(e, ref.service, usecaseID)
+		entry.Trace = l.ua.sites(prependSite(e.Trace, usecase))
+		entries = appendEntry(entries, entry)
+	}
+
+	for _, edge := range l.edges[ref] {
+		// the errors of the called usecase pass through the provider call and the calls leading to it
+		via := l.ua.sites(prependSite(edge.call.path(), usecase))
+		for _, nested := range l.catalogue[edge.to.service][edge.to.usecase] {
+			if edge.call.Handled.Has(nested.Code) {
+				if l.verbose {
+					debugf("Skip error %s handled after %s in %s", nested, edge.call, usecaseID)
+				}
+				continue
+			}
+			nested.Chain = append([]string{usecaseID}, nested.Chain...)
+			nested.Trace = prependSite(nested.Trace, via...)
+			entries = appendEntry(entries, nested)
+		}
+	}
+	return entries
+}
+
+// cyclePath returns the path of the cycle from the usecase back to it inside the component,
+// ex: users.Login → otp.GenerateCode → users.Login
+func (l *linker) cyclePath(start usecaseRef, members map[usecaseRef]bool) string {
+	// breadth-first search of the shortest way back to the start
+	prev := map[usecaseRef]usecaseRef{}
+	queue := []usecaseRef{start}
+	seen := map[usecaseRef]bool{}
+	var last usecaseRef
+	found := false
+	for len(queue) > 0 && !found {
+		ref := queue[0]
+		queue = queue[1:]
+		for _, edge := range l.edges[ref] {
+			if edge.to == start {
+				last, found = ref, true
+				break
+			}
+			if members[edge.to] && !seen[edge.to] {
+				seen[edge.to] = true
+				prev[edge.to] = ref
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+
+	path := []string{start.String()}
+	for ref := last; ref != start; ref = prev[ref] {
+		path = append([]string{ref.String()}, path...)
+	}
+	return start.String() + " → " + strings.Join(path, " → ")
+}
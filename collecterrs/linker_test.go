@@ -0,0 +1,82 @@
+package collecterrs
+
+import (
+	"go/token"
+	"strings"
+	"testing"
+)
+
+// usecase returns the summary of a usecase returning the errors and calling the usecases of other services:
+// "b.Pong" is a call of the method Pong through the provider field B
+func usecase(codes []string, calls ...string) *FuncErrors {
+	fe := &FuncErrors{}
+	for _, code := range codes {
+		fe.AddError(NamedError{Code: code})
+	}
+	for _, call := range calls {
+		provider, method, _ := strings.Cut(call, ".")
+		fe.AddCall(ProviderCall{Provider: strings.ToUpper(provider), Method: method})
+	}
+	return fe
+}
+
+func catalogueCodes(c Catalogue, service, usecase string) string {
+	var codes []string
+	for _, e := range c[service][usecase] {
+		codes = append(codes, e.Service+"."+e.Code)
+	}
+	return strings.Join(codes, " ")
+}
+
+func TestLinkCycle(t *testing.T) {
+	// a.Ping → b.Pong → c.Pang → a.Ping, c.Pang handles A1 after calling a.Ping; a.Solo calls into the cycle
+	pang := usecase([]string{"C1"})
+	pang.AddCall(ProviderCall{Provider: "A", Method: "Ping", Handled: HandledErrors{"A1": token.Position{}}})
+	errs := map[string]map[string]*FuncErrors{
+		"a": {
+			"Ping": usecase([]string{"A1"}, "b.Pong"),
+			"Solo": usecase(nil, "b.Pong"),
+		},
+		"b": {"Pong": usecase([]string{"B1"}, "c.Pang")},
+		"c": {"Pang": pang},
+	}
+
+	ua := NewUsecaseAnalysis()
+	c := ua.LinkProviderErrors(errs, false)
+
+	for _, tt := range []struct{ service, usecase, want string }{
+		{"a", "Ping", "a.A1 b.B1 c.C1"},
+		{"b", "Pong", "b.B1 c.C1"},
+		{"c", "Pang", "c.C1 b.B1"},
+		{"a", "Solo", "b.B1 c.C1"},
+	} {
+		if got := catalogueCodes(c, tt.service, tt.usecase); got != tt.want {
+			t.Errorf("%s.%s: got %s, want %s", tt.service, tt.usecase, got, tt.want)
+		}
+	}
+
+	want := []string{"usecases call each other in a cycle: a.Ping → b.Pong → c.Pang → a.Ping"}
+	if got := ua.Warnings(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got warnings %q, want %q", got, want)
+	}
+	for _, e := range c["a"]["Ping"] {
+		if e.Code == "C1" && strings.Join(e.Chain, " → ") != "a.Ping → b.Pong → c.Pang" {
+			t.Errorf("got chain %v of C1 in a.Ping", e.Chain)
+		}
+	}
+}
+
+func TestLinkSelfCall(t *testing.T) {
+	errs := map[string]map[string]*FuncErrors{
+		"a": {"Retry": usecase([]string{"A1"}, "a.Retry")},
+	}
+	ua := NewUsecaseAnalysis()
+	c := ua.LinkProviderErrors(errs, false)
+	if got := catalogueCodes(c, "a", "Retry"); got != "a.A1" {
+		t.Errorf("got %s, want a.A1", got)
+	}
+	want := "usecases call each other in a cycle: a.Retry → a.Retry"
+	if got := ua.Warnings(); len(got) != 1 || got[0] != want {
+		t.Errorf("got warnings %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,289 @@
+package collecterrs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// protoErrorsOption is the method option of errors.proto listing the codes the rpc can return:
+// rpc Login(LoginReq) returns (LoginResp) { option (errors.possible) = "UserBlocked"; }
+const protoErrorsOption = "(errors.possible)"
+
+var (
+	protoRPCRe     = regexp.MustCompile(`\brpc\s+(\w+)\s*\([^)]*\)\s*returns\s*\([^)]*\)\s*([;{])`)
+	protoOptionRe  = regexp.MustCompile(`[ \t]*option\s*\(\s*errors\.possible\s*\)\s*=\s*"([^"]*)"\s*;[ \t]*\n?`)
+	protoImportRe  = regexp.MustCompile(`(?m)^import\s+(?:public\s+|weak\s+)?"([^"]+)"\s*;[^\n]*\n`)
+	protoPackageRe = regexp.MustCompile(`(?m)^package\s+[\w.]+\s*;[^\n]*\n`)
+)
+
+// ProtoFile is the .proto file declaring the proto service registered by a service of the project
+type ProtoFile struct {
+	Path    string // Path of the file
+	Service string // Service of the project, ex: users
+	Proto   *ProtoService
+	content []byte
+}
+
+// ProtoMismatch is an rpc whose (errors.possible) options differ from the analyzed errors
+type ProtoMismatch struct {
+	Path    string
+	RPC     string   // Empty if only the import of errors.proto is missing
+	Missing []string // Analyzed codes not listed in the options
+	Extra   []string // Listed codes the rpc can not return
+}
+
+func (m ProtoMismatch) String() string {
+	if m.RPC == "" {
+		return m.Path + ": errors.proto is not imported"
+	}
+	var parts []string
+	if len(m.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(m.Missing, ", "))
+	}
+	if len(m.Extra) > 0 {
+		parts = append(parts, "not returned "+strings.Join(m.Extra, ", "))
+	}
+	return fmt.Sprintf("%s: rpc %s: %s", m.Path, m.RPC, strings.Join(parts, "; "))
+}
+
+// ProtoFiles finds the .proto files of the proto services registered by the services,
+// next to the generated Go packages
+func (r *Report) ProtoFiles() ([]*ProtoFile, error) {
+	var files []*ProtoFile
+	for _, service := range sortedKeys(r.Protos) {
+		proto := r.Protos[service]
+		if proto.Dir == "" {
+			return nil, fmt.Errorf("%s: directory of the generated package %s is unknown", service, proto.Pkg)
+		}
+		paths, err := filepath.Glob(filepath.Join(proto.Dir, "*.proto"))
+		if err != nil {
+			return nil, err
+		}
+		var file *ProtoFile
+		for _, path := range paths {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if _, _, ok := protoServiceBlock(content, maskProto(content), proto.Name); ok {
+				file = &ProtoFile{Path: path, Service: service, Proto: proto, content: content}
+				break
+			}
+		}
+		if file == nil {
+			return nil, fmt.Errorf("%s: service %s is not declared in the .proto files of %s", service, proto.Name, proto.Dir)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Update returns the content of the file with the codes of the errors of every rpc written as (errors.possible) options,
+// and the rpcs whose options differ from the errors. Other options of the rpcs are kept.
+// importPath is the path errors.proto is imported by, it is added to the imports if any rpc has errors
+func (f *ProtoFile) Update(usecases map[string][]ErrorEntry, importPath string) ([]byte, []ProtoMismatch, error) {
+	src := f.content
+	mask := maskProto(src)
+	open, end, ok := protoServiceBlock(src, mask, f.Proto.Name)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: service %s not found", f.Path, f.Proto.Name)
+	}
+
+	var out bytes.Buffer
+	var mismatches []ProtoMismatch
+	hasErrors := false
+	last := 0
+	for _, m := range protoRPCRe.FindAllSubmatchIndex(mask[open:end], -1) {
+		start, name, term := open+m[0], string(src[open+m[2]:open+m[3]]), open+m[4]
+		stop := term + 1
+		var body, bodyMask []byte
+		if src[term] == '{' {
+			closing := matchBrace(mask, term)
+			if closing < 0 {
+				return nil, nil, fmt.Errorf("%s: rpc %s: unbalanced braces", f.Path, name)
+			}
+			body, bodyMask = src[term+1:closing], mask[term+1:closing]
+			stop = closing + 1
+		}
+
+		// existing options are found in the mask, to skip commented out ones, and read from the source
+		var listed []string
+		var rest []byte
+		from := 0
+		for _, o := range protoOptionRe.FindAllSubmatchIndex(bodyMask, -1) {
+			listed = append(listed, string(body[o[2]:o[3]]))
+			rest = append(rest, body[from:o[0]]...)
+			from = o[1]
+		}
+		rest = append(rest, body[from:]...)
+
+		var codes []string
+		for _, e := range usecases[name] {
+			codes = appendUnique(codes, e.Code)
+		}
+		hasErrors = hasErrors || len(codes) > 0
+		if missing, extra := diffCodes(listed, codes); len(missing) > 0 || len(extra) > 0 {
+			mismatches = append(mismatches, ProtoMismatch{Path: f.Path, RPC: name, Missing: missing, Extra: extra})
+		}
+
+		out.Write(src[last:start])
+		out.WriteString(renderRPC(src[start:term], lineIndent(src, start), codes, rest))
+		last = stop
+	}
+	out.Write(src[last:])
+
+	updated := out.Bytes()
+	if hasErrors && !importsProto(updated, importPath) {
+		updated = addProtoImport(updated, importPath)
+		if len(mismatches) == 0 {
+			mismatches = append(mismatches, ProtoMismatch{Path: f.Path})
+		}
+	}
+	return updated, mismatches, nil
+}
+
+// Changed checks that the updated content differs from the file
+func (f *ProtoFile) Changed(updated []byte) bool {
+	return !bytes.Equal(f.content, updated)
+}
+
+// renderRPC writes the rpc declaration with the options, or terminated by ; if it has no options
+func renderRPC(head []byte, indent string, codes []string, rest []byte) string {
+	var lines []string
+	for _, code := range codes {
+		lines = append(lines, fmt.Sprintf("%s  option %s = %q;", indent, protoErrorsOption, code))
+	}
+	for _, line := range strings.Split(string(rest), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.TrimRight(line, " \t"))
+		}
+	}
+	decl := strings.TrimRight(string(head), " \t\n")
+	if len(lines) == 0 {
+		return decl + ";"
+	}
+	return decl + " {\n" + strings.Join(lines, "\n") + "\n" + indent + "}"
+}
+
+// diffCodes returns the codes missing from the listed ones and the listed codes that are not expected
+func diffCodes(listed, codes []string) (missing, extra []string) {
+	has := map[string]bool{}
+	for _, code := range listed {
+		has[code] = true
+	}
+	expected := map[string]bool{}
+	for _, code := range codes {
+		expected[code] = true
+		if !has[code] {
+			missing = append(missing, code)
+		}
+	}
+	for _, code := range listed {
+		if !expected[code] {
+			extra = append(extra, code)
+		}
+	}
+	return missing, extra
+}
+
+func importsProto(src []byte, importPath string) bool {
+	for _, m := range protoImportRe.FindAllSubmatch(src, -1) {
+		if string(m[1]) == importPath {
+			return true
+		}
+	}
+	return false
+}
+
+// addProtoImport adds the import after the last import, or after the package declaration
+func addProtoImport(src []byte, importPath string) []byte {
+	line := fmt.Sprintf("import %q;\n", importPath)
+	at := -1
+	if imports := protoImportRe.FindAllIndex(src, -1); len(imports) > 0 {
+		at = imports[len(imports)-1][1]
+	} else if pkg := protoPackageRe.FindIndex(src); pkg != nil {
+		at = pkg[1]
+		line = "\n" + line
+	}
+	if at < 0 {
+		return append([]byte(line), src...)
+	}
+	return append(append(append([]byte{}, src[:at]...), line...), src[at:]...)
+}
+
+// protoServiceBlock returns the positions of the braces of the service declaration
+func protoServiceBlock(src, mask []byte, name string) (int, int, bool) {
+	re := regexp.MustCompile(`\bservice\s+` + regexp.QuoteMeta(name) + `\s*\{`)
+	m := re.FindIndex(mask)
+	if m == nil {
+		return 0, 0, false
+	}
+	open := m[1] - 1
+	end := matchBrace(mask, open)
+	return open, end, end >= 0
+}
+
+// matchBrace returns the position of the brace closing the one at open
+func matchBrace(mask []byte, open int) int {
+	depth := 0
+	for i := open; i < len(mask); i++ {
+		switch mask[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// maskProto blanks out comments and the contents of string literals, keeping the positions and line breaks,
+// so the declarations are matched only in the code
+func maskProto(src []byte) []byte {
+	mask := bytes.Clone(src)
+	for i := 0; i < len(mask); i++ {
+		switch {
+		case mask[i] == '/' && i+1 < len(mask) && mask[i+1] == '/':
+			for ; i < len(mask) && mask[i] != '\n'; i++ {
+				mask[i] = ' '
+			}
+		case mask[i] == '/' && i+1 < len(mask) && mask[i+1] == '*':
+			for ; i < len(mask) && !(mask[i] == '*' && i+1 < len(mask) && mask[i+1] == '/'); i++ {
+				if mask[i] != '\n' {
+					mask[i] = ' '
+				}
+			}
+			for j := i; j < i+2 && j < len(mask); j++ {
+				mask[j] = ' '
+			}
+			i++
+		case mask[i] == '"' || mask[i] == '\'':
+			quote := mask[i]
+			for i++; i < len(mask) && mask[i] != quote && mask[i] != '\n'; i++ {
+				if mask[i] == '\\' && i+1 < len(mask) {
+					mask[i] = ' '
+					i++
+				}
+				mask[i] = ' '
+			}
+		}
+	}
+	return mask
+}
+
+// lineIndent returns the whitespace the line of the position starts with
+func lineIndent(src []byte, pos int) string {
+	start := bytes.LastIndexByte(src[:pos], '\n') + 1
+	end := start
+	for end < len(src) && (src[end] == ' ' || src[end] == '\t') {
+		end++
+	}
+	return string(src[start:end])
+}
@@ -0,0 +1,232 @@
+package collecterrs
+
+import (
+	"strings"
+	"testing"
+)
+
+const errorsImport = "specs/proto/errors/errors.proto"
+
+func codes(codes ...string) []ErrorEntry {
+	var entries []ErrorEntry
+	for _, code := range codes {
+		entries = append(entries, ErrorEntry{Code: code, Service: "users"})
+	}
+	return entries
+}
+
+func TestProtoFileUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		src        string
+		usecases   map[string][]ErrorEntry
+		want       string
+		mismatches []string
+	}{
+		{
+			name: "new option",
+			src: `syntax = "proto3";
+
+package users;
+
+import "google/protobuf/any.proto";
+
+service Users {
+  rpc HealthCheck (HealthCheckReq) returns (HealthCheckResp); // generated
+  rpc Login(LoginReq) returns (LoginResp);
+}
+`,
+			usecases: map[string][]ErrorEntry{"Login": codes("UserBlocked", "InvalidCode")},
+			want: `syntax = "proto3";
+
+package users;
+
+import "google/protobuf/any.proto";
+import "specs/proto/errors/errors.proto";
+
+service Users {
+  rpc HealthCheck (HealthCheckReq) returns (HealthCheckResp); // generated
+  rpc Login(LoginReq) returns (LoginResp) {
+    option (errors.possible) = "UserBlocked";
+    option (errors.possible) = "InvalidCode";
+  }
+}
+`,
+			mismatches: []string{"users.proto: rpc Login: missing UserBlocked, InvalidCode"},
+		},
+		{
+			name: "replaced option",
+			src: `syntax = "proto3";
+
+package users;
+
+import "specs/proto/errors/errors.proto";
+
+service Users {
+  rpc Login(LoginReq) returns (LoginResp) {
+    option (errors.possible) = "UserBlocked";
+    option (errors.possible) = "Removed";
+  }
+  rpc Logout(LogoutReq) returns (LogoutResp) {
+    option (errors.possible) = "UserBlocked";
+  }
+}
+`,
+			usecases: map[string][]ErrorEntry{"Login": codes("UserBlocked", "InvalidCode")},
+			want: `syntax = "proto3";
+
+package users;
+
+import "specs/proto/errors/errors.proto";
+
+service Users {
+  rpc Login(LoginReq) returns (LoginResp) {
+    option (errors.possible) = "UserBlocked";
+    option (errors.possible) = "InvalidCode";
+  }
+  rpc Logout(LogoutReq) returns (LogoutResp);
+}
+`,
+			mismatches: []string{
+				"users.proto: rpc Login: missing InvalidCode; not returned Removed",
+				"users.proto: rpc Logout: not returned UserBlocked",
+			},
+		},
+		{
+			name: "body with other options",
+			src: `syntax = "proto3";
+
+package users;
+
+service Users {
+  rpc Login(LoginReq) returns (LoginResp) {
+    option deprecated = true;
+  }
+  rpc Logout(LogoutReq) returns (LogoutResp) {}
+  rpc Refresh(RefreshReq) returns (RefreshResp) {
+  }
+}
+`,
+			usecases: map[string][]ErrorEntry{"Login": codes("UserBlocked"), "Logout": codes("UserBlocked")},
+			want: `syntax = "proto3";
+
+package users;
+
+import "specs/proto/errors/errors.proto";
+
+service Users {
+  rpc Login(LoginReq) returns (LoginResp) {
+    option (errors.possible) = "UserBlocked";
+    option deprecated = true;
+  }
+  rpc Logout(LogoutReq) returns (LogoutResp) {
+    option (errors.possible) = "UserBlocked";
+  }
+  rpc Refresh(RefreshReq) returns (RefreshResp);
+}
+`,
+			mismatches: []string{
+				"users.proto: rpc Login: missing UserBlocked",
+				"users.proto: rpc Logout: missing UserBlocked",
+			},
+		},
+		{
+			name: "comments",
+			src: `syntax = "proto3";
+
+package users;
+
+import "specs/proto/errors/errors.proto";
+
+service Users {
+  // rpc Commented(Req) returns (Resp);
+  rpc Login(LoginReq) returns (LoginResp) /* the body { is below */ {
+    // option (errors.possible) = "Commented";
+    option (errors.possible) = "UserBlocked";
+  }
+}
+`,
+			usecases: map[string][]ErrorEntry{"Login": codes("UserBlocked")},
+			want: `syntax = "proto3";
+
+package users;
+
+import "specs/proto/errors/errors.proto";
+
+service Users {
+  // rpc Commented(Req) returns (Resp);
+  rpc Login(LoginReq) returns (LoginResp) /* the body { is below */ {
+    option (errors.possible) = "UserBlocked";
+    // option (errors.possible) = "Commented";
+  }
+}
+`,
+		},
+		{
+			name: "import present",
+			src: `syntax = "proto3";
+
+package users;
+
+import "google/protobuf/any.proto";
+import "specs/proto/errors/errors.proto"; // error contracts
+
+service Users {
+  rpc Login(LoginReq) returns (LoginResp);
+}
+`,
+			usecases: map[string][]ErrorEntry{"Login": codes("UserBlocked", "UserBlocked")},
+			want: `syntax = "proto3";
+
+package users;
+
+import "google/protobuf/any.proto";
+import "specs/proto/errors/errors.proto"; // error contracts
+
+service Users {
+  rpc Login(LoginReq) returns (LoginResp) {
+    option (errors.possible) = "UserBlocked";
+  }
+}
+`,
+			mismatches: []string{"users.proto: rpc Login: missing UserBlocked"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &ProtoFile{Path: "users.proto", Service: "users", Proto: &ProtoService{Name: "Users"}, content: []byte(tt.src)}
+			updated, mismatches, err := f.Update(tt.usecases, errorsImport)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(updated) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", updated, tt.want)
+			}
+			var got []string
+			for _, m := range mismatches {
+				got = append(got, m.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.mismatches, "\n") {
+				t.Errorf("got mismatches\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.mismatches, "\n"))
+			}
+
+			// the updated file is up to date
+			f.content = updated
+			again, mismatches, err := f.Update(tt.usecases, errorsImport)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.Changed(again) || len(mismatches) > 0 {
+				t.Errorf("updated file is changed again: %v\n%s", mismatches, again)
+			}
+		})
+	}
+}
+
+func TestProtoFileUpdateMissingService(t *testing.T) {
+	f := &ProtoFile{Path: "users.proto", Proto: &ProtoService{Name: "Users"}, content: []byte("service Otp {}\n// service Users {}\n")}
+	if _, _, err := f.Update(nil, errorsImport); err == nil {
+		t.Error("commented out service is updated")
+	}
+}
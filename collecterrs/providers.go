@@ -0,0 +1,163 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ProviderIndex maps types of the fields of the Providers structs to the field names,
+// so a call of a method on a value of such type is a provider call however the value is obtained:
+// u.Providers.Otp.ValidateCode(...), an alias otpClient := u.Providers.Otp or a function parameter
+type ProviderIndex struct {
+	names map[*types.TypeName]string // Field type → provider name
+}
+
+func NewProviderIndex() *ProviderIndex {
+	return &ProviderIndex{names: make(map[*types.TypeName]string)}
+}
+
+// Collect adds fields of the Providers structs of the package: the struct type named Providers
+// if the package declares providers (config/services/<svc>), and types of the struct fields named Providers.
+// Returns fields whose type is already added under another name
+func (x *ProviderIndex) Collect(pkg *packages.Package, declares bool) []string {
+	var conflicts []string
+	add := func(st *types.Struct) {
+		for i := 0; i < st.NumFields(); i++ {
+			field := st.Field(i)
+			tn := typeName(field.Type())
+			if tn == nil {
+				continue
+			}
+			if name, ok := x.names[tn]; ok && name != field.Name() {
+				if protoClient(tn) != "" {
+					continue // gRPC clients are linked by the proto service, not by the name
+				}
+				conflicts = append(conflicts, field.Name()+" "+tn.Name()+" (already "+name+")")
+				continue
+			}
+			x.names[tn] = field.Name()
+		}
+	}
+
+	scope := pkg.Types.Scope()
+	if declares {
+		if tn, ok := scope.Lookup("Providers").(*types.TypeName); ok {
+			if st, ok := tn.Type().Underlying().(*types.Struct); ok {
+				add(st)
+			}
+		}
+	}
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok {
+			continue
+		}
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if field := st.Field(i); field.Name() == "Providers" {
+				if providers, ok := derefType(field.Type()).Underlying().(*types.Struct); ok {
+					add(providers)
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// Provider returns the call of the method on a value of the provider type.
+// A package is not a provider for itself: storageImpl calling its embedded Storage is not a provider call
+func (x *ProviderIndex) Provider(recv types.Type, method string, caller *types.Package) (ProviderCall, bool) {
+	tn := typeName(recv)
+	if tn == nil || tn.Pkg() == caller {
+		return ProviderCall{}, false
+	}
+	name, ok := x.names[tn]
+	if !ok {
+		return ProviderCall{}, false
+	}
+	return ProviderCall{Provider: name, Method: method, Client: protoClient(tn), method: lookupMethod(recv, method, tn.Pkg())}, true
+}
+
+// Types returns the types of the provider fields with the name
+func (x *ProviderIndex) Types(name string) []*types.TypeName {
+	var fields []*types.TypeName
+	for _, tn := range sortedTypeNames(x.names) {
+		if x.names[tn] == name {
+			fields = append(fields, tn)
+		}
+	}
+	return fields
+}
+
+// lookupMethod returns the method of the type by the name, the interface method for interfaces
+func lookupMethod(t types.Type, name string, pkg *types.Package) *types.Func {
+	if !types.IsInterface(t) {
+		t = types.NewPointer(derefType(t))
+	}
+	sel := types.NewMethodSet(t).Lookup(pkg, name)
+	if sel == nil {
+		return nil
+	}
+	fn, ok := sel.Obj().(*types.Func)
+	if !ok {
+		return nil
+	}
+	return fn.Origin()
+}
+
+// Call returns the call of a provider method
+func (x *ProviderIndex) Call(call *ast.CallExpr, info *types.Info, caller *types.Package) (ProviderCall, bool) {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok {
+		return ProviderCall{}, false
+	}
+	selection := info.Selections[sel]
+	if selection == nil || selection.Kind() != types.MethodVal {
+		return ProviderCall{}, false
+	}
+	return x.Provider(selection.Recv(), sel.Sel.Name, caller)
+}
+
+// protoClient returns the key of the proto service, if the type is its generated gRPC client interface:
+// otp.OtpClient is the client of the Otp service, when the package declares Otp_ServiceDesc
+func protoClient(tn *types.TypeName) string {
+	service, ok := strings.CutSuffix(tn.Name(), "Client")
+	if !ok || !types.IsInterface(tn.Type()) {
+		return ""
+	}
+	desc, ok := tn.Pkg().Scope().Lookup(service + "_ServiceDesc").(*types.Var)
+	if !ok {
+		return ""
+	}
+	if descType := typeName(desc.Type()); descType == nil || descType.Name() != "ServiceDesc" {
+		return ""
+	}
+	return protoKey(tn.Pkg().Path(), service)
+}
+
+// protoKey identifies the proto service by the generated package and the service name
+func protoKey(pkgPath, service string) string {
+	return pkgPath + "." + service
+}
+
+// typeName returns the declared type of the value or of the value the pointer points to
+func typeName(t types.Type) *types.TypeName {
+	named, ok := types.Unalias(derefType(t)).(*types.Named)
+	if !ok {
+		return nil
+	}
+	return named.Origin().Obj()
+}
+
+func derefType(t types.Type) types.Type {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		return ptr.Elem()
+	}
+	return t
+}
@@ -0,0 +1,151 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ErrorDecl is a named error declared with errs.NewServiceError(code, type, description)
+type ErrorDecl struct {
+	Code        string
+	Type        string
+	Description string
+	Var         string // Declared variable as pkgPath.Name, empty for inline declarations
+	Pos         token.Position
+}
+
+// ErrorRegistry holds declarations of all named errors found in the loaded packages,
+// so that the analyzer reports the real code that clients get in ServerError.Code
+type ErrorRegistry struct {
+	byVar  map[string]*ErrorDecl // pkgPath.Name → declaration
+	byCode map[string]*ErrorDecl // Code → declaration
+}
+
+func NewErrorRegistry() *ErrorRegistry {
+	return &ErrorRegistry{
+		byVar:  make(map[string]*ErrorDecl),
+		byCode: make(map[string]*ErrorDecl),
+	}
+}
+
+// Collect parses every NewServiceError call of the package
+func (r *ErrorRegistry) Collect(pkg *packages.Package) {
+	if pkg.TypesInfo == nil {
+		return
+	}
+	for _, file := range pkg.Syntax {
+		// package-level variables first, they are referenced by the name
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, value := range vs.Values {
+					if i >= len(vs.Names) {
+						break
+					}
+					call, ok := ast.Unparen(value).(*ast.CallExpr)
+					if !ok {
+						continue
+					}
+					if d := r.declFromCall(call, pkg); d != nil {
+						d.Var = pkg.PkgPath + "." + vs.Names[i].Name
+						r.byVar[d.Var] = d
+						r.add(d)
+					}
+				}
+			}
+		}
+
+		// inline declarations, for example return errs.NewServiceError(...)
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if d := r.declFromCall(call, pkg); d != nil {
+					r.add(d)
+				}
+			}
+			return true
+		})
+	}
+}
+
+// Lookup returns the declaration of the named error variable
+func (r *ErrorRegistry) Lookup(v *types.Var) *ErrorDecl {
+	return r.byVar[varKey(v)]
+}
+
+// LookupCode returns the declaration of the named error by its code
+func (r *ErrorRegistry) LookupCode(code string) *ErrorDecl {
+	return r.byCode[code]
+}
+
+func (r *ErrorRegistry) add(d *ErrorDecl) {
+	if _, exists := r.byCode[d.Code]; !exists {
+		r.byCode[d.Code] = d
+	}
+}
+
+func (r *ErrorRegistry) declFromCall(call *ast.CallExpr, pkg *packages.Package) *ErrorDecl {
+	if !isNewServiceErrorCall(call, pkg.TypesInfo) || len(call.Args) < 3 {
+		return nil
+	}
+	code, ok := constString(call.Args[0], pkg.TypesInfo)
+	if !ok {
+		return nil
+	}
+	errType, _ := constString(call.Args[1], pkg.TypesInfo)
+	description, _ := constString(call.Args[2], pkg.TypesInfo)
+	return &ErrorDecl{
+		Code:        code,
+		Type:        errType,
+		Description: description,
+		Pos:         pkg.Fset.Position(call.Pos()),
+	}
+}
+
+// isNewServiceErrorCall checks that the call is errs.NewServiceError
+func isNewServiceErrorCall(call *ast.CallExpr, info *types.Info) bool {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return false
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	return ok && isNewServiceErrorFunc(fn)
+}
+
+// isNewServiceErrorFunc checks that the function is a constructor of ServiceError named NewServiceError
+func isNewServiceErrorFunc(fn *types.Func) bool {
+	if fn.Name() != "NewServiceError" {
+		return false
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Recv() != nil || sig.Results().Len() != 1 {
+		return false
+	}
+	named, ok := types.Unalias(sig.Results().At(0).Type()).(*types.Named)
+	return ok && named.Obj().Name() == "ServiceError"
+}
+
+// constString returns the value of a constant string expression, including typed constants like errs.Type
+func constString(expr ast.Expr, info *types.Info) (string, bool) {
+	tv, ok := info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", false
+	}
+	return constant.StringVal(tv.Value), true
+}
+
+func varKey(v *types.Var) string {
+	return v.Pkg().Path() + "." + v.Name()
+}
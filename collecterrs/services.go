@@ -0,0 +1,330 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/types"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Service is a service found in the project by the services pattern of the config
+type Service struct {
+	Name   string        // Service name, camelCase of the directory name
+	Dir    string        // Directory relative to the module
+	Pkg    string        // Import path of the service directory
+	Layers [][]string    // Import paths of packages of every layer, from the lowest one
+	Proto  *ProtoService // gRPC service registered by the service, nil if not found
+}
+
+// ProtoService is a gRPC service, found by the generated RegisterXServer call
+type ProtoService struct {
+	Name    string   // Proto service name, ex: Otp
+	Pkg     string   // Import path of the generated package
+	Dir     string   // Directory of the generated package, containing the .proto file
+	Methods []string // RPC names
+}
+
+// Key identifies the proto service for its gRPC clients, see ProviderCall.Client
+func (p *ProtoService) Key() string {
+	return protoKey(p.Pkg, p.Name)
+}
+
+// isServicePkg checks that the package belongs to the service directory
+func (s *Service) isServicePkg(pkgPath string) bool {
+	return pkgPath == s.Pkg || strings.HasPrefix(pkgPath, s.Pkg+"/")
+}
+
+// findProtoService finds the gRPC service registered in any package of the service,
+// ex: pb.RegisterUsersServer(srv, s)
+func (ua *UsecaseAnalysis) findProtoService(service *Service) *ProtoService {
+	for _, pkgPath := range sortedKeys(ua.packages) {
+		pkg := ua.packages[pkgPath]
+		if !service.isServicePkg(pkgPath) || pkg.TypesInfo == nil {
+			continue
+		}
+		var proto *ProtoService
+		for _, file := range pkg.Syntax {
+			ast.Inspect(file, func(n ast.Node) bool {
+				call, ok := n.(*ast.CallExpr)
+				if !ok || proto != nil {
+					return proto == nil
+				}
+				proto = protoServiceFromRegister(call, pkg.TypesInfo)
+				return proto == nil
+			})
+		}
+		if proto != nil {
+			if generated := ua.packages[proto.Pkg]; generated != nil && len(generated.GoFiles) > 0 {
+				proto.Dir = filepath.Dir(generated.GoFiles[0])
+			}
+			return proto
+		}
+	}
+	return nil
+}
+
+// protoServiceFromRegister describes the proto service by the generated RegisterXServer(s grpc.ServiceRegistrar, srv XServer) call
+func protoServiceFromRegister(call *ast.CallExpr, info *types.Info) *ProtoService {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+	fn, ok := info.Uses[ident].(*types.Func)
+	if !ok || !strings.HasPrefix(fn.Name(), "Register") || !strings.HasSuffix(fn.Name(), "Server") {
+		return nil
+	}
+	sig := fn.Type().(*types.Signature)
+	if sig.Params().Len() != 2 {
+		return nil
+	}
+	named, ok := types.Unalias(sig.Params().At(1).Type()).(*types.Named)
+	if !ok {
+		return nil
+	}
+	iface, ok := named.Underlying().(*types.Interface)
+	if !ok {
+		return nil
+	}
+
+	proto := &ProtoService{
+		Name: strings.TrimSuffix(strings.TrimPrefix(fn.Name(), "Register"), "Server"),
+		Pkg:  fn.Pkg().Path(),
+	}
+	for i := 0; i < iface.NumMethods(); i++ {
+		if m := iface.Method(i); m.Exported() {
+			proto.Methods = append(proto.Methods, m.Name())
+		}
+	}
+	return proto
+}
+
+// usecaseMethods returns declarations of the usecases of the package.
+// Usecases are the methods of the exported usecase interface implemented by the usecases type,
+// or the RPCs of the registered proto service if there is no such interface.
+// They are resolved through the method set of the type, so methods of other types with the same names are not usecases.
+// Mismatches between the interface, the type and the proto service are reported as warnings
+func (ua *UsecaseAnalysis) usecaseMethods(pkg *packages.Package, service *Service) map[*ast.FuncDecl]bool {
+	name := serviceName(service, pkg)
+	var proto *ProtoService
+	if service != nil {
+		proto = service.Proto
+	}
+	declared := declaredImplementations(pkg)
+	impl := ua.usecaseImplementation(pkg, declared)
+	if impl == nil {
+		return ua.usecasesByName(pkg, name, proto)
+	}
+
+	var ifaceNames []string
+	var usecaseNames []string
+	for _, iface := range usecaseInterfaces(pkg, impl, declared) {
+		ifaceNames = append(ifaceNames, iface.Name())
+		it := iface.Type().Underlying().(*types.Interface)
+		for i := 0; i < it.NumMethods(); i++ {
+			usecaseNames = append(usecaseNames, it.Method(i).Name())
+		}
+	}
+	declaredBy := strings.Join(ifaceNames, ", ")
+	switch {
+	case len(ifaceNames) > 0 && proto != nil:
+		ua.reportUsecaseMismatch(name, declaredBy, usecaseNames, proto)
+	case len(ifaceNames) == 0 && proto != nil:
+		usecaseNames = proto.Methods
+		declaredBy = "rpcs of " + proto.Name
+	case len(ifaceNames) == 0:
+		ua.warnf("%s: no usecase interface or proto service found, all exported methods of %s are analyzed", pkg.PkgPath, impl.Name())
+	}
+
+	decls := methodDecls(pkg)
+	usecases := map[*ast.FuncDecl]bool{}
+	isUsecase := map[string]bool{}
+	for _, usecase := range usecaseNames {
+		isUsecase[usecase] = true
+		fn, abstract := resolveMethod(impl, usecase)
+		switch {
+		case fn == nil:
+			ua.warnf("%s: usecase %s has no implementation in %s", name, usecase, impl.Name())
+		case abstract:
+			ua.warnf("%s: usecase %s is not implemented by %s, it is only promoted from the embedded interface", name, usecase, impl.Name())
+		case decls[fn] == nil:
+			ua.warnf("%s: usecase %s is implemented outside of %s, it is not analyzed", name, usecase, pkg.PkgPath)
+		default:
+			usecases[decls[fn]] = true
+		}
+	}
+	// exported methods missing from the interface are helpers or the interface is behind the implementation,
+	// they are analyzed only if there is no interface or proto service at all
+	for _, fn := range declaredMethods(impl) {
+		if isUsecase[fn.Name()] || decls[fn] == nil {
+			continue
+		}
+		if len(usecaseNames) > 0 {
+			ua.warnf("%s: method %s.%s is not a usecase of %s, it is not analyzed", name, impl.Name(), fn.Name(), declaredBy)
+			continue
+		}
+		usecases[decls[fn]] = true
+	}
+	return usecases
+}
+
+// usecaseImplementation returns the type implementing the usecases: the type with the Providers field,
+// or the type declared as the implementation of an exported interface of the package, nil if there is none
+func (ua *UsecaseAnalysis) usecaseImplementation(pkg *packages.Package, declared map[*types.TypeName][]*types.TypeName) *types.TypeName {
+	var candidates []*types.TypeName
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || tn.IsAlias() {
+			continue
+		}
+		st, ok := tn.Type().Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if st.Field(i).Name() == "Providers" {
+				candidates = append(candidates, tn)
+				break
+			}
+		}
+	}
+	isDeclared := map[*types.TypeName]bool{}
+	for _, iface := range sortedTypeNames(declared) {
+		if !iface.Exported() || iface.Pkg() != pkg.Types {
+			continue
+		}
+		for _, impl := range declared[iface] {
+			isDeclared[impl] = true
+		}
+	}
+	if len(candidates) == 0 {
+		for impl := range isDeclared {
+			candidates = append(candidates, impl)
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name() < candidates[j].Name() })
+	}
+	return ua.pickImplementation(pkg.PkgPath, "the usecases", candidates, isDeclared)
+}
+
+// usecaseInterfaces returns the exported interfaces of the package the type implements,
+// only the ones declared for the type if there are such: helper interfaces like Clock are not usecases
+func usecaseInterfaces(pkg *packages.Package, impl *types.TypeName, declared map[*types.TypeName][]*types.TypeName) []*types.TypeName {
+	var all, forImpl []*types.TypeName
+	scope := pkg.Types.Scope()
+	for _, name := range scope.Names() {
+		tn, ok := scope.Lookup(name).(*types.TypeName)
+		if !ok || !tn.Exported() || tn.IsAlias() {
+			continue
+		}
+		it, ok := tn.Type().Underlying().(*types.Interface)
+		if !ok || it.NumMethods() == 0 || !implements(impl, tn) {
+			continue
+		}
+		all = append(all, tn)
+		for _, d := range declared[tn] {
+			if d == impl {
+				forImpl = append(forImpl, tn)
+			}
+		}
+	}
+	if len(forImpl) > 0 {
+		return forImpl
+	}
+	return all
+}
+
+// usecasesByName returns the methods named as the RPCs of the proto service, or all exported methods,
+// when the package has no type implementing the usecases. Names declared by several types are ambiguous
+func (ua *UsecaseAnalysis) usecasesByName(pkg *packages.Package, name string, proto *ProtoService) map[*ast.FuncDecl]bool {
+	methods := map[string][]*ast.FuncDecl{}
+	for _, decl := range methodDecls(pkg) {
+		methods[decl.Name.Name] = append(methods[decl.Name.Name], decl)
+	}
+
+	var usecaseNames []string
+	if proto != nil {
+		usecaseNames = proto.Methods
+	} else {
+		ua.warnf("%s: no usecase interface or proto service found, all exported methods are analyzed", pkg.PkgPath)
+		for method := range methods {
+			if ast.IsExported(method) {
+				usecaseNames = append(usecaseNames, method)
+			}
+		}
+		sort.Strings(usecaseNames)
+	}
+
+	usecases := map[*ast.FuncDecl]bool{}
+	for _, usecase := range usecaseNames {
+		switch decls := methods[usecase]; len(decls) {
+		case 0:
+			ua.warnf("%s: usecase %s has no implementation in %s", name, usecase, pkg.PkgPath)
+		case 1:
+			usecases[decls[0]] = true
+		default:
+			ua.warnf("%s: usecase %s is declared by several types in %s, it is not analyzed", name, usecase, pkg.PkgPath)
+		}
+	}
+	return usecases
+}
+
+// sortedTypeNames returns the keys of the map sorted by the package and the name
+func sortedTypeNames[V any](m map[*types.TypeName]V) []*types.TypeName {
+	keys := make([]*types.TypeName, 0, len(m))
+	for tn := range m {
+		keys = append(keys, tn)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Pkg().Path() != keys[j].Pkg().Path() {
+			return keys[i].Pkg().Path() < keys[j].Pkg().Path()
+		}
+		return keys[i].Name() < keys[j].Name()
+	})
+	return keys
+}
+
+// reportUsecaseMismatch warns about the usecases that are not RPCs and RPCs that are not usecases
+func (ua *UsecaseAnalysis) reportUsecaseMismatch(service, iface string, usecaseNames []string, proto *ProtoService) {
+	usecases := map[string]bool{}
+	for _, name := range usecaseNames {
+		usecases[name] = true
+	}
+	rpcs := map[string]bool{}
+	for _, name := range proto.Methods {
+		rpcs[name] = true
+		if !usecases[name] {
+			ua.warnf("%s: rpc %s.%s is not a method of the usecase interface %s", service, proto.Name, name, iface)
+		}
+	}
+	sort.Strings(usecaseNames)
+	for _, name := range usecaseNames {
+		if !rpcs[name] {
+			ua.warnf("%s: usecase %s of the interface %s is not an rpc of the proto service %s", service, name, iface, proto.Name)
+		}
+	}
+}
+
+func serviceName(service *Service, pkg *packages.Package) string {
+	if service != nil {
+		return service.Name
+	}
+	return pkg.PkgPath
+}
+
+// serviceOf returns the service the package belongs to
+func (ua *UsecaseAnalysis) serviceOf(pkgPath string) *Service {
+	for _, service := range ua.services {
+		if service.isServicePkg(pkgPath) {
+			return service
+		}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package collecterrs
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"io"
+	"strings"
+)
+
+// Kinds of the sites an error passes through
+const (
+	SiteUsecase  = "usecase"  // Declaration of the usecase the error is returned from
+	SiteCall     = "call"     // Call of a function of the module returning the error
+	SiteProvider = "provider" // Provider call returning the error of the called layer or usecase
+	SiteReturn   = "return"   // Return statement of the named error
+)
+
+// Site is a position in the sources an error passes through
+type Site struct {
+	Kind string `json:"kind"` // SiteUsecase, SiteCall, SiteProvider or SiteReturn
+	Name string `json:"name"` // Usecase, called function, provider method or returned expression
+	Pos  string `json:"pos"`  // Relative to the module directory
+
+	pos token.Position // Set while analyzing, Pos is set from it for the report
+}
+
+func (s Site) String() string {
+	return fmt.Sprintf("%s: %s %s", s.Pos, s.Kind, s.Name)
+}
+
+// prependSite returns a new trace starting with the sites
+func prependSite(trace []Site, sites ...Site) []Site {
+	return append(append(make([]Site, 0, len(sites)+len(trace)), sites...), trace...)
+}
+
+// HandledErrors are codes of the errors excluded by checks, with the positions of the checks
+type HandledErrors map[string]token.Position
+
+func (h HandledErrors) Has(code string) bool {
+	_, ok := h[code]
+	return ok
+}
+
+// HandledError is an error excluded from the errors of the usecase by a check
+type HandledError struct {
+	Usecase string `json:"usecase"` // ex: users.ConfirmLogin
+	Code    string `json:"code"`
+	Pos     string `json:"pos"` // Position of the check, relative to the module directory
+}
+
+// sites sets the positions of the sites relative to the module directory
+func (ua *UsecaseAnalysis) sites(trace []Site) []Site {
+	result := make([]Site, len(trace))
+	for i, s := range trace {
+		if s.Pos == "" {
+			s.Pos = ua.position(s.pos)
+		}
+		result[i] = s
+	}
+	return result
+}
+
+// funcName returns the short name of the function: pkg.Func or Type.Method
+func funcName(fn *types.Func) string {
+	if recv := fn.Type().(*types.Signature).Recv(); recv != nil {
+		if tn := typeName(recv.Type()); tn != nil {
+			return tn.Name() + "." + fn.Name()
+		}
+		return fn.Name()
+	}
+	if fn.Pkg() != nil {
+		return fn.Pkg().Name() + "." + fn.Name()
+	}
+	return fn.Name()
+}
+
+// Explain writes the sites the error passes through from the usecase down to its return,
+// or the checks handling it if the usecase can not return the error. The id is service.usecase.code
+func (r *Report) Explain(w io.Writer, id string) error {
+	parts := strings.SplitN(id, ".", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("explain %q: expected service.usecase.code", id)
+	}
+	service, usecase, code := parts[0], parts[1], parts[2]
+	entries, ok := r.Services[service][usecase]
+	if !ok {
+		return fmt.Errorf("explain %q: usecase %s.%s not found", id, service, usecase)
+	}
+
+	for _, e := range entries {
+		if e.Code != code {
+			continue
+		}
+		fmt.Fprintf(w, "%s.%s → %s.%s [%s] %s\n", service, usecase, e.Service, e.Code, e.Type, e.Description)
+		for _, s := range e.Trace {
+			fmt.Fprintf(w, "  %s\n", s)
+		}
+		if e.Declared != "" {
+			fmt.Fprintf(w, "  %s: declared\n", e.Declared)
+		}
+		return nil
+	}
+
+	found := false
+	for _, h := range r.Handled {
+		if h.Usecase == service+"."+usecase && h.Code == code {
+			if !found {
+				fmt.Fprintf(w, "%s.%s does not return %s, it is handled\n", service, usecase, code)
+			}
+			fmt.Fprintf(w, "  %s: handled\n", h.Pos)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("explain %q: %s.%s can not return %s", id, service, usecase, code)
+	}
+	return nil
+}
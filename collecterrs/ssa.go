@@ -0,0 +1,762 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// SSAAnalyzer follows error values in SSA form from the return instructions back to their origins:
+// named errors, calls of the package functions and provider calls.
+// Unlike the AST analysis it respects control flow: code behind constant conditions is skipped,
+// reassigned and shadowed variables are different values, and errors.Is checks exclude errors
+// only from the returns they guard
+type SSAAnalyzer struct {
+	prog      *ssa.Program
+	module    string // Module path, calls of the functions of the module are followed into
+	resolver  *ErrorResolver
+	funcs     *FuncIndex // Implementations of the interfaces called not through providers
+	providers *ProviderIndex
+	summaries *SummaryCache[*ssa.Function]
+	params    map[*ssa.Function]map[int]bool // Analyzed function → parameters it returns wrapped or as is
+}
+
+// NewSSAAnalyzer creates SSA packages for the loaded packages and their dependencies,
+// function bodies are built on demand
+func NewSSAAnalyzer(pkgs []*packages.Package, module, errsPkgPath string, registry *ErrorRegistry, funcs *FuncIndex, providers *ProviderIndex) *SSAAnalyzer {
+	prog, _ := ssautil.AllPackages(pkgs, 0)
+	return &SSAAnalyzer{
+		prog:      prog,
+		module:    module,
+		resolver:  NewErrorResolver(nil, errsPkgPath, registry, nil),
+		funcs:     funcs,
+		providers: providers,
+		summaries: NewSummaryCache[*ssa.Function](),
+		params:    make(map[*ssa.Function]map[int]bool),
+	}
+}
+
+// FuncErrors returns errors of the declared function
+func (a *SSAAnalyzer) FuncErrors(pkg *packages.Package, decl *ast.FuncDecl) *FuncErrors {
+	obj, ok := pkg.TypesInfo.Defs[decl.Name].(*types.Func)
+	if !ok {
+		return &FuncErrors{}
+	}
+	ssaPkg := a.prog.Package(obj.Pkg())
+	if ssaPkg == nil {
+		return &FuncErrors{}
+	}
+	ssaPkg.Build()
+	fn := a.prog.FuncValue(obj)
+	if fn == nil {
+		return &FuncErrors{}
+	}
+	return a.analyze(fn)
+}
+
+func (a *SSAAnalyzer) analyze(fn *ssa.Function) *FuncErrors {
+	return a.summaries.Summary(fn, func(fe *FuncErrors) {
+		a.analyzeFunc(fn, fe)
+	})
+}
+
+func (a *SSAAnalyzer) analyzeFunc(fn *ssa.Function, fe *FuncErrors) {
+	if a.params[fn] == nil {
+		a.params[fn] = make(map[int]bool)
+	}
+	if len(fn.Blocks) == 0 {
+		return
+	}
+
+	f := &ssaFunc{
+		analyzer:  a,
+		fn:        fn,
+		result:    fe,
+		reachable: reachableBlocks(fn),
+		calls:     astCalls(fn.Syntax()),
+		binaries:  astBinaries(fn.Syntax()),
+	}
+	returns := astReturns(fn.Syntax())
+	for _, b := range fn.Blocks {
+		if !f.reachable[b] {
+			continue
+		}
+		ret, ok := b.Instrs[len(b.Instrs)-1].(*ssa.Return)
+		if !ok {
+			continue
+		}
+		for i, v := range ret.Results {
+			if isErrorValue(v) {
+				f.ret = Site{Kind: SiteReturn, pos: a.prog.Fset.Position(ret.Pos())}
+				if stmt := returns[ret.Pos()]; stmt != nil && len(stmt.Results) == len(ret.Results) {
+					f.ret.Name = types.ExprString(stmt.Results[i])
+				}
+				f.trace(v, b, nil, fe, make(seenValues))
+			}
+		}
+	}
+}
+
+// ssaFunc traces returned values of one function
+type ssaFunc struct {
+	analyzer  *SSAAnalyzer
+	fn        *ssa.Function
+	result    *FuncErrors // Errors returned by the function
+	reachable map[*ssa.BasicBlock]bool
+	calls     map[token.Pos]*ast.CallExpr   // Calls of the function body by the opening parenthesis
+	binaries  map[token.Pos]*ast.BinaryExpr // Binary expressions of the function body by the operator
+	ret       Site                          // Return statement being traced
+}
+
+// seenValues are values traced with the handled codes: a value reached on another path,
+// where fewer errors are handled, is traced again
+type seenValues map[seenKey]bool
+
+type seenKey struct {
+	v       ssa.Value
+	handled string // Sorted handled codes
+}
+
+// trace adds origins of the error value used in the block.
+// handled are codes excluded by the checks the value has passed
+func (f *ssaFunc) trace(v ssa.Value, block *ssa.BasicBlock, handled HandledErrors, fe *FuncErrors, seen seenValues) {
+	handled = f.checkedAt(v, block, handled)
+	key := seenKey{v: v, handled: strings.Join(slices.Sorted(maps.Keys(handled)), " ")}
+	if seen[key] {
+		return
+	}
+	seen[key] = true
+
+	switch v := v.(type) {
+	case *ssa.Phi:
+		// a variable assigned in several branches, only reachable branches are followed
+		for i, edge := range v.Edges {
+			if pred := v.Block().Preds[i]; f.reachable[pred] {
+				f.trace(edge, pred, handled, fe, seen)
+			}
+		}
+	case *ssa.Parameter:
+		// the function returns the error of the caller
+		if fe == f.result {
+			for i, param := range f.fn.Params {
+				if param == v {
+					f.analyzer.params[f.fn][i] = true
+				}
+			}
+		}
+	case *ssa.Slice:
+		// variadic arguments: errors.Join(errs...)
+		for _, elem := range sliceElems(v) {
+			if elem != nil {
+				f.trace(elem, block, handled, fe, seen)
+			}
+		}
+	case *ssa.MakeInterface:
+		if hasUnwrap(v.X.Type()) {
+			// &wrapError{err: err}
+			f.traceFields(v.X, block, handled, fe, seen)
+		}
+		f.trace(v.X, block, handled, fe, seen)
+	case *ssa.ChangeInterface:
+		f.trace(v.X, block, handled, fe, seen)
+	case *ssa.ChangeType:
+		f.trace(v.X, block, handled, fe, seen)
+	case *ssa.TypeAssert:
+		if !v.CommaOk {
+			f.trace(v.X, block, handled, fe, seen)
+		}
+	case *ssa.UnOp:
+		if v.Op != token.MUL {
+			return
+		}
+		switch x := v.X.(type) {
+		case *ssa.Global:
+			if named, ok := f.globalError(x); ok {
+				addUnlessHandled(fe, named.at(f.ret), handled)
+			}
+		case *ssa.Alloc:
+			if hasUnwrap(x.Type()) {
+				// wrapError{err: err}
+				f.traceFields(x, block, handled, fe, seen)
+			}
+			// a variable captured by a closure or taken by address is not lifted to registers
+			for _, ref := range *x.Referrers() {
+				if store, ok := ref.(*ssa.Store); ok && store.Addr == x && f.reachable[store.Block()] {
+					f.trace(store.Val, store.Block(), handled, fe, seen)
+				}
+			}
+		}
+	case *ssa.Extract:
+		if call, ok := v.Tuple.(*ssa.Call); ok {
+			f.traceCall(call, block, handled, fe, seen)
+		}
+	case *ssa.Call:
+		f.traceCall(v, block, handled, fe, seen)
+	}
+}
+
+// traceCall adds errors returned by the call
+func (f *ssaFunc) traceCall(call *ssa.Call, block *ssa.BasicBlock, handled HandledErrors, fe *FuncErrors, seen seenValues) {
+	common := call.Common()
+	// Provider calls: methods of the types of the Providers fields, u.Providers.<Provider>.<Method> or an alias
+	if providerCall, ok := f.providerCall(common); ok {
+		providerCall.Handled = handled
+		providerCall.Pos = f.analyzer.prog.Fset.Position(f.callPos(call))
+		fe.AddCall(providerCall)
+		fe.AddReturned(providerCall)
+		return
+	}
+	if callee := common.StaticCallee(); callee != nil {
+		switch {
+		case f.isNewServiceError(callee):
+			// Inline declaration errs.NewServiceError("Code", ...)
+			if c, ok := common.Args[0].(*ssa.Const); ok && c.Value != nil && c.Value.Kind() == constant.String {
+				code := constant.StringVal(c.Value)
+				named := NamedError{Code: code, Decl: f.analyzer.resolver.registry.LookupCode(code), Trace: []Site{f.ret}}
+				addUnlessHandled(fe, named, handled)
+			}
+		case f.isServiceErrorMethod(callee, "WithDetails"):
+			// Extract error from the base error before WithDetails, details from the map literal
+			base := &FuncErrors{}
+			f.trace(common.Args[0], block, handled, base, seen)
+			for _, e := range base.Errors {
+				if astCall := f.calls[call.Pos()]; astCall != nil && len(astCall.Args) > 0 {
+					e.Details = extractMapKeys(astCall.Args[0])
+				}
+				fe.AddError(e)
+			}
+		case isSSAPkgFunc(callee, "fmt", "Errorf"):
+			// fmt.Errorf("ctx: %w", err)
+			format, ok := common.Args[0].(*ssa.Const)
+			if !ok || format.Value == nil || format.Value.Kind() != constant.String {
+				return
+			}
+			elems := sliceElems(common.Args[1])
+			for _, i := range wrappedArgs(constant.StringVal(format.Value)) {
+				if i-1 < len(elems) && elems[i-1] != nil {
+					f.trace(elems[i-1], block, handled, fe, seen)
+				}
+			}
+		case isSSAPkgFunc(callee, "errors", "Join"):
+			f.trace(common.Args[0], block, handled, fe, seen)
+		case f.analyzer.inModule(callee):
+			f.traceModuleCall(callee, common.Args, f.callPos(call), block, handled, fe, seen)
+		}
+		return
+	}
+
+	// Other interfaces are dispatched to the single implementation in the interface package
+	if common.IsInvoke() {
+		if impl := f.analyzer.funcs.Implementation(common.Method); impl != nil {
+			if callee := f.analyzer.prog.FuncValue(impl); callee != nil && f.analyzer.inModule(callee) {
+				args := append([]ssa.Value{common.Value}, common.Args...)
+				f.traceModuleCall(callee, args, f.callPos(call), block, handled, fe, seen)
+			}
+		}
+	}
+}
+
+// providerCall returns the call of a method of the provider type
+func (f *ssaFunc) providerCall(common *ssa.CallCommon) (ProviderCall, bool) {
+	var recv types.Type
+	var method string
+	if common.IsInvoke() {
+		recv, method = common.Value.Type(), common.Method.Name()
+	} else if callee := common.StaticCallee(); callee != nil && callee.Signature.Recv() != nil {
+		recv, method = callee.Signature.Recv().Type(), callee.Name()
+	} else {
+		return ProviderCall{}, false
+	}
+	var caller *types.Package
+	if f.fn.Pkg != nil {
+		caller = f.fn.Pkg.Pkg
+	}
+	return f.analyzer.providers.Provider(recv, method, caller)
+}
+
+// traceModuleCall adds errors of the function of the module, its summary is shared by all callers.
+// The args include the receiver of a method
+func (f *ssaFunc) traceModuleCall(callee *ssa.Function, args []ssa.Value, pos token.Pos, block *ssa.BasicBlock, handled HandledErrors, fe *FuncErrors, seen seenValues) {
+	nested := f.analyzer.analyze(callee)
+	site := Site{Kind: SiteCall, Name: callee.Name(), pos: f.analyzer.prog.Fset.Position(pos)}
+	if obj, ok := callee.Object().(*types.Func); ok {
+		site = callSite(obj, site.pos)
+	}
+	for _, e := range nested.Errors {
+		addUnlessHandled(fe, e.at(site), handled)
+	}
+	for _, c := range nested.Calls {
+		fe.AddCall(c.WithHandled(handled).at(site))
+	}
+	for _, c := range nested.Returned {
+		fe.AddReturned(c.WithHandled(handled).at(site))
+	}
+	// arguments the callee returns wrapped or as is
+	for i := range f.analyzer.params[callee] {
+		if i < len(args) {
+			f.trace(args[i], block, handled, fe, seen)
+		}
+	}
+}
+
+// traceFields adds errors stored into the error fields of the struct the pointer points to
+func (f *ssaFunc) traceFields(ptr ssa.Value, block *ssa.BasicBlock, handled HandledErrors, fe *FuncErrors, seen seenValues) {
+	refs := ptr.Referrers()
+	if refs == nil {
+		return
+	}
+	for _, ref := range *refs {
+		fa, ok := ref.(*ssa.FieldAddr)
+		if !ok {
+			continue
+		}
+		for _, fieldRef := range *fa.Referrers() {
+			if store, ok := fieldRef.(*ssa.Store); ok && store.Addr == fa && isErrorValue(store.Val) && f.reachable[store.Block()] {
+				f.trace(store.Val, block, handled, fe, seen)
+			}
+		}
+	}
+}
+
+// checkedAt adds codes of the checks of the value, which the block can be reached only after failing:
+//
+//	if err != nil && !errors.Is(err, errsUsers.UserNotFoundError) {
+//		return nil, err // UserNotFound is handled
+//	}
+//
+// The checks are errors.Is, custom Is, comparisons and errors.As followed by switch serviceErr.Code.
+// A code is handled, if every path to the block goes through an edge where the value can not be this error
+func (f *ssaFunc) checkedAt(v ssa.Value, block *ssa.BasicBlock, handled HandledErrors) HandledErrors {
+	refs := v.Referrers()
+	if refs == nil {
+		return handled
+	}
+	cuts := map[string][]ssaEdge{}   // Code → edges where the value is not this error
+	checks := map[string]token.Pos{} // Code → the first check
+	addCuts := func(codes []string, edges []ssaEdge, pos token.Pos) {
+		for _, code := range codes {
+			cuts[code] = append(cuts[code], edges...)
+			if _, ok := checks[code]; !ok {
+				checks[code] = pos
+			}
+		}
+	}
+	for _, ref := range *refs {
+		if !f.reachable[ref.Block()] {
+			continue
+		}
+		switch ref := ref.(type) {
+		case *ssa.Call:
+			if target := f.isTarget(ref, v); target != nil {
+				addCuts(f.errorCodes(target), failedEdges(ref, false), f.callPos(ref))
+			}
+			if target := f.asTarget(ref, v); target != nil {
+				// the value is not a ServiceError at all, or its code differs
+				asFailed := failedEdges(ref, false)
+				for _, code := range codeLoads(target) {
+					for _, codeRef := range *code.Referrers() {
+						if op, ok := codeRef.(*ssa.BinOp); ok && isComparison(op) {
+							addCuts(f.codeConsts(compared(op, code)), append(failedEdges(op, op.Op == token.NEQ), asFailed...), f.comparedPos(op, code))
+						}
+					}
+				}
+			}
+		case *ssa.BinOp:
+			if isComparison(ref) {
+				addCuts(f.errorCodes(compared(ref, v)), failedEdges(ref, ref.Op == token.NEQ), f.comparedPos(ref, v))
+			}
+		}
+	}
+	for _, code := range sortedKeys(cuts) {
+		if !f.reachableWithout(block, cuts[code]) {
+			handled = withCode(handled, code, f.analyzer.prog.Fset.Position(checks[code]))
+		}
+	}
+	return handled
+}
+
+// ssaEdge is an edge of the control flow graph
+type ssaEdge struct {
+	from, to *ssa.BasicBlock
+}
+
+// failedEdges returns the edges taken when the condition is false (true, if it is negated)
+func failedEdges(cond ssa.Value, negated bool) []ssaEdge {
+	refs := cond.Referrers()
+	if refs == nil {
+		return nil
+	}
+	var edges []ssaEdge
+	for _, ref := range *refs {
+		if ifInstr, ok := ref.(*ssa.If); ok {
+			succ := ifInstr.Block().Succs[1]
+			if negated {
+				succ = ifInstr.Block().Succs[0]
+			}
+			edges = append(edges, ssaEdge{from: ifInstr.Block(), to: succ})
+		}
+	}
+	return edges
+}
+
+// reachableWithout checks that the block is reachable from the entry, when the edges are removed
+func (f *ssaFunc) reachableWithout(target *ssa.BasicBlock, cut []ssaEdge) bool {
+	if len(cut) == 0 {
+		return true
+	}
+	removed := make(map[ssaEdge]bool, len(cut))
+	for _, e := range cut {
+		removed[e] = true
+	}
+	visited := make(map[*ssa.BasicBlock]bool)
+	queue := []*ssa.BasicBlock{f.fn.Blocks[0]}
+	if f.fn.Recover != nil {
+		queue = append(queue, f.fn.Recover)
+	}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		if visited[b] {
+			continue
+		}
+		if b == target {
+			return true
+		}
+		visited[b] = true
+		for _, succ := range liveSuccs(b) {
+			if !removed[ssaEdge{from: b, to: succ}] {
+				queue = append(queue, succ)
+			}
+		}
+	}
+	return false
+}
+
+func isComparison(op *ssa.BinOp) bool {
+	return op.Op == token.EQL || op.Op == token.NEQ
+}
+
+// compared returns the operand of the comparison other than v
+func compared(op *ssa.BinOp, v ssa.Value) ssa.Value {
+	if op.X == v {
+		return op.Y
+	}
+	return op.X
+}
+
+// callPos returns the start of the call expression, as the AST mode reports the call, ssa.Call.Pos is the opening parenthesis
+func (f *ssaFunc) callPos(call *ssa.Call) token.Pos {
+	if astCall := f.calls[call.Pos()]; astCall != nil {
+		return astCall.Pos()
+	}
+	return call.Pos()
+}
+
+// comparedPos returns the start of the operand v is compared with, as the AST mode reports the check.
+// ssa.BinOp.Pos is the operator of a binary expression and the case expression of a switch
+func (f *ssaFunc) comparedPos(op *ssa.BinOp, v ssa.Value) token.Pos {
+	expr := f.binaries[op.Pos()]
+	if expr == nil {
+		return op.Pos()
+	}
+	if op.X == v {
+		return expr.Y.Pos()
+	}
+	return expr.X.Pos()
+}
+
+// asTarget returns the pointer passed to errors.As(v, &serviceErr), nil for other calls
+func (f *ssaFunc) asTarget(call *ssa.Call, v ssa.Value) ssa.Value {
+	common := call.Common()
+	callee := common.StaticCallee()
+	if callee == nil || len(common.Args) != 2 || common.Args[0] != v || !isSSAPkgFunc(callee, "errors", "As") {
+		return nil
+	}
+	target := common.Args[1]
+	if mi, ok := target.(*ssa.MakeInterface); ok {
+		target = mi.X
+	}
+	return target
+}
+
+// codeLoads returns the loaded values of the Code field of the ServiceError the pointer points to
+func codeLoads(ptr ssa.Value) []ssa.Value {
+	var loads []ssa.Value
+	refs := ptr.Referrers()
+	if refs == nil {
+		return nil
+	}
+	for _, ref := range *refs {
+		switch ref := ref.(type) {
+		case *ssa.FieldAddr:
+			if fieldName(ref) != "Code" {
+				continue
+			}
+			for _, fieldRef := range *ref.Referrers() {
+				if load, ok := fieldRef.(*ssa.UnOp); ok && load.Op == token.MUL {
+					loads = append(loads, load)
+				}
+			}
+		case *ssa.UnOp:
+			// errors.As(err, &serviceErrPtr) with serviceErrPtr *errs.ServiceError
+			if ref.Op == token.MUL {
+				if _, ok := ref.Type().Underlying().(*types.Pointer); ok {
+					loads = append(loads, codeLoads(ref)...)
+				}
+			}
+		}
+	}
+	return loads
+}
+
+// codeConsts returns the code the value holds: a constant or errsX.Y.Code
+func (f *ssaFunc) codeConsts(v ssa.Value) []string {
+	switch v := v.(type) {
+	case *ssa.Const:
+		if v.Value != nil && v.Value.Kind() == constant.String {
+			return []string{constant.StringVal(v.Value)}
+		}
+	case *ssa.UnOp:
+		fa, ok := v.X.(*ssa.FieldAddr)
+		if !ok || v.Op != token.MUL || fieldName(fa) != "Code" {
+			return nil
+		}
+		if g, ok := fa.X.(*ssa.Global); ok {
+			if named, ok := f.globalError(g); ok {
+				return []string{named.Code}
+			}
+		}
+	}
+	return nil
+}
+
+func fieldName(fa *ssa.FieldAddr) string {
+	ptr, ok := fa.X.Type().Underlying().(*types.Pointer)
+	if !ok {
+		return ""
+	}
+	st, ok := ptr.Elem().Underlying().(*types.Struct)
+	if !ok {
+		return ""
+	}
+	return st.Field(fa.Field).Name()
+}
+
+func isSSAPkgFunc(fn *ssa.Function, pkgPath, name string) bool {
+	obj, ok := fn.Object().(*types.Func)
+	return ok && isPkgFunc(obj, pkgPath, name)
+}
+
+// isTarget returns the target of errors.Is(v, target) or of the custom target.Is(v), nil for other calls
+func (f *ssaFunc) isTarget(call *ssa.Call, v ssa.Value) ssa.Value {
+	common := call.Common()
+	callee := common.StaticCallee()
+	if callee == nil || len(common.Args) != 2 {
+		return nil
+	}
+	switch {
+	case isSSAPkgFunc(callee, "errors", "Is"):
+		if common.Args[0] == v {
+			return common.Args[1]
+		}
+	case f.isServiceErrorMethod(callee, "Is"):
+		if common.Args[1] == v {
+			return common.Args[0]
+		}
+	}
+	return nil
+}
+
+// errorCodes returns codes of the named errors the value can hold
+func (f *ssaFunc) errorCodes(v ssa.Value) []string {
+	fe := &FuncErrors{}
+	f.trace(v, f.fn.Blocks[0], nil, fe, make(seenValues))
+	var codes []string
+	for _, e := range fe.Errors {
+		codes = append(codes, e.Code)
+	}
+	return codes
+}
+
+// globalError returns the named error stored in the package-level variable
+func (f *ssaFunc) globalError(g *ssa.Global) (NamedError, bool) {
+	v, ok := g.Object().(*types.Var)
+	if !ok || !f.analyzer.resolver.isServiceError(v.Type()) {
+		return NamedError{}, false
+	}
+	return f.analyzer.resolver.Error(v), true
+}
+
+func (f *ssaFunc) isNewServiceError(fn *ssa.Function) bool {
+	obj, ok := fn.Object().(*types.Func)
+	return ok && isNewServiceErrorFunc(obj) && len(fn.Params) > 0
+}
+
+func (f *ssaFunc) isServiceErrorMethod(fn *ssa.Function, name string) bool {
+	recv := fn.Signature.Recv()
+	return fn.Name() == name && recv != nil && f.analyzer.resolver.isServiceError(recv.Type())
+}
+
+// reachableBlocks returns the blocks reachable from the entry, branches of constant conditions are not followed:
+//
+//	if true {
+//		return errsDummy.DummyError
+//	}
+//	// unreachable
+func reachableBlocks(fn *ssa.Function) map[*ssa.BasicBlock]bool {
+	reachable := make(map[*ssa.BasicBlock]bool)
+	var visit func(b *ssa.BasicBlock)
+	visit = func(b *ssa.BasicBlock) {
+		if reachable[b] {
+			return
+		}
+		reachable[b] = true
+		for _, succ := range liveSuccs(b) {
+			visit(succ)
+		}
+	}
+	visit(fn.Blocks[0])
+	if fn.Recover != nil {
+		visit(fn.Recover)
+	}
+	return reachable
+}
+
+// liveSuccs returns successors of the block, skipping the branch of a constant condition that is never taken
+func liveSuccs(b *ssa.BasicBlock) []*ssa.BasicBlock {
+	if ifInstr, ok := b.Instrs[len(b.Instrs)-1].(*ssa.If); ok {
+		if c, ok := ifInstr.Cond.(*ssa.Const); ok && c.Value != nil {
+			if constant.BoolVal(c.Value) {
+				return b.Succs[:1]
+			}
+			return b.Succs[1:]
+		}
+	}
+	return b.Succs
+}
+
+// astCalls indexes calls of the function syntax by the opening parenthesis, as ssa.Call.Pos reports it
+func astCalls(syntax ast.Node) map[token.Pos]*ast.CallExpr {
+	calls := make(map[token.Pos]*ast.CallExpr)
+	if syntax == nil {
+		return calls
+	}
+	ast.Inspect(syntax, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			calls[call.Lparen] = call
+		}
+		return true
+	})
+	return calls
+}
+
+// astBinaries indexes binary expressions of the function syntax by the operator, as ssa.BinOp.Pos reports it
+func astBinaries(syntax ast.Node) map[token.Pos]*ast.BinaryExpr {
+	binaries := make(map[token.Pos]*ast.BinaryExpr)
+	if syntax == nil {
+		return binaries
+	}
+	ast.Inspect(syntax, func(n ast.Node) bool {
+		if expr, ok := n.(*ast.BinaryExpr); ok {
+			binaries[expr.OpPos] = expr
+		}
+		return true
+	})
+	return binaries
+}
+
+// astReturns indexes return statements of the function syntax by the return keyword, as ssa.Return.Pos reports it
+func astReturns(syntax ast.Node) map[token.Pos]*ast.ReturnStmt {
+	returns := make(map[token.Pos]*ast.ReturnStmt)
+	if syntax == nil {
+		return returns
+	}
+	ast.Inspect(syntax, func(n ast.Node) bool {
+		if ret, ok := n.(*ast.ReturnStmt); ok {
+			returns[ret.Return] = ret
+		}
+		return true
+	})
+	return returns
+}
+
+// inModule checks that the function is declared in the analyzed module, building its package if needed
+func (a *SSAAnalyzer) inModule(fn *ssa.Function) bool {
+	if fn.Pkg == nil || fn.Synthetic != "" {
+		return false
+	}
+	path := fn.Pkg.Pkg.Path()
+	if path != a.module && !strings.HasPrefix(path, a.module+"/") {
+		return false
+	}
+	fn.Pkg.Build()
+	return len(fn.Blocks) > 0
+}
+
+// sliceElems returns values stored into the array of the slice, as the compiler passes variadic arguments.
+// The values are indexed by the position in the array, nil if the element is not stored
+func sliceElems(v ssa.Value) []ssa.Value {
+	slice, ok := v.(*ssa.Slice)
+	if !ok {
+		return nil
+	}
+	array, ok := slice.X.(*ssa.Alloc)
+	if !ok {
+		return nil
+	}
+	var elems []ssa.Value
+	for _, ref := range *array.Referrers() {
+		addr, ok := ref.(*ssa.IndexAddr)
+		if !ok {
+			continue
+		}
+		index, ok := addr.Index.(*ssa.Const)
+		if !ok {
+			continue
+		}
+		i := int(index.Int64())
+		for _, addrRef := range *addr.Referrers() {
+			if store, ok := addrRef.(*ssa.Store); ok && store.Addr == addr {
+				for len(elems) <= i {
+					elems = append(elems, nil)
+				}
+				elems[i] = store.Val
+			}
+		}
+	}
+	return elems
+}
+
+func isErrorValue(v ssa.Value) bool {
+	return types.Implements(v.Type(), errorInterface)
+}
+
+// addUnlessHandled adds the error, or records the check handling it
+func addUnlessHandled(fe *FuncErrors, e NamedError, handled HandledErrors) {
+	if pos, ok := handled[e.Code]; ok {
+		fe.AddHandled(HandledErrors{e.Code: pos})
+		return
+	}
+	fe.AddError(e)
+}
+
+// withCode returns a copy of the handled codes with the code checked at the position added
+func withCode(handled HandledErrors, code string, pos token.Position) HandledErrors {
+	if handled.Has(code) {
+		return handled
+	}
+	result := make(HandledErrors, len(handled)+1)
+	for c, p := range handled {
+		result[c] = p
+	}
+	result[code] = pos
+	return result
+}
@@ -0,0 +1,77 @@
+package collecterrs
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestSSATracesMatchAST checks that both modes report the same sites of the errors found by both of them:
+// calls and checks are positioned at the start of the expression, not at the parenthesis or the operator
+func TestSSATracesMatchAST(t *testing.T) {
+	if testing.Short() {
+		t.Skip("loads the example project")
+	}
+	analyze := func(mode string) *Report {
+		cfg := DefaultConfig()
+		cfg.ModuleDir = "../project"
+		cfg.Mode = mode
+		report, err := NewUsecaseAnalysis().Analyze(cfg, false)
+		if err != nil {
+			t.Fatalf("%s: %v", mode, err)
+		}
+		return report
+	}
+	ast, ssa := analyze(ModeAST), analyze(ModeSSA)
+
+	compared := 0
+	for service, usecases := range ssa.Services {
+		for usecase, entries := range usecases {
+			astEntries := map[string]ErrorEntry{}
+			for _, e := range ast.Services[service][usecase] {
+				astEntries[e.Service+"."+e.Code] = e
+			}
+			for _, e := range entries {
+				id := e.Service + "." + e.Code
+				astEntry, ok := astEntries[id]
+				if !ok {
+					continue // the AST mode does not follow the control flow, the modes can find different errors
+				}
+				compared++
+				if got, want := fmt.Sprint(e.Trace), fmt.Sprint(astEntry.Trace); got != want {
+					t.Errorf("%s.%s: %s:\nssa %s\nast %s", service, usecase, id, got, want)
+				}
+			}
+		}
+	}
+	if compared == 0 {
+		t.Fatal("the modes have no errors in common")
+	}
+
+	astChecks := map[string]string{}
+	for _, h := range ast.Handled {
+		astChecks[h.Usecase+" "+h.Code] = h.Pos
+	}
+	for _, h := range ssa.Handled {
+		if pos, ok := astChecks[h.Usecase+" "+h.Code]; ok && pos != h.Pos {
+			t.Errorf("%s: %s is handled at %s, at %s in the AST mode", h.Usecase, h.Code, h.Pos, pos)
+		}
+	}
+}
+
+// TestSSAControlFlow checks the errors the SSA mode follows through the control flow of the fixture
+func TestSSAControlFlow(t *testing.T) {
+	report := analyzeFlow(t, ModeSSA)
+	for _, tt := range []struct{ usecase, want string }{
+		{"Phi", "Left Right"},
+		{"Reassign", "Reassigned"},
+		{"Shadow", "Outer"},
+		// UserNotFound is handled only by the first return
+		{"Later", "UserBlocked UserNotFound"},
+		// the same value reaches the return through a branch handling UserNotFound and through the other one
+		{"HandledOnOnePath", "Left UserBlocked UserNotFound"},
+	} {
+		if got := reportCodes(report, tt.usecase); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.usecase, got, tt.want)
+		}
+	}
+}
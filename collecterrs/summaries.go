@@ -0,0 +1,159 @@
+package collecterrs
+
+import "go/token"
+
+// FuncErrors collects what a function can return:
+// named errors and provider calls, whose errors are inserted later
+type FuncErrors struct {
+	Errors   []NamedError
+	Calls    []ProviderCall
+	Returned []ProviderCall // Provider calls whose errors the function returns directly, ex: return nil, err
+	Handled  HandledErrors  // Codes of errors the function handles after its calls
+	Pos      token.Position // Declaration of the usecase or the layer method
+}
+
+// AddError adds the named error once, only the trace of its first return site is kept
+func (fe *FuncErrors) AddError(e NamedError) {
+	for _, existing := range fe.Errors {
+		if existing.String() == e.String() {
+			return
+		}
+	}
+	fe.Errors = append(fe.Errors, e)
+}
+
+func (fe *FuncErrors) AddCall(c ProviderCall) {
+	fe.Calls = addCall(fe.Calls, c)
+	fe.AddHandled(c.Handled)
+}
+
+func (fe *FuncErrors) AddReturned(c ProviderCall) {
+	fe.Returned = addCall(fe.Returned, c)
+}
+
+func (fe *FuncErrors) AddHandled(handled HandledErrors) {
+	for code, pos := range handled {
+		if fe.Handled == nil {
+			fe.Handled = make(HandledErrors)
+		}
+		if !fe.Handled.Has(code) {
+			fe.Handled[code] = pos
+		}
+	}
+}
+
+// merge adds everything the other summary has, and reports whether the summary has grown
+func (fe *FuncErrors) merge(other *FuncErrors) bool {
+	size := fe.size()
+	for _, e := range other.Errors {
+		fe.AddError(e)
+	}
+	for _, c := range other.Calls {
+		fe.AddCall(c)
+	}
+	for _, c := range other.Returned {
+		fe.AddReturned(c)
+	}
+	fe.AddHandled(other.Handled)
+	return fe.size() > size
+}
+
+func (fe *FuncErrors) size() int {
+	return len(fe.Errors) + len(fe.Calls) + len(fe.Returned) + len(fe.Handled)
+}
+
+func addCall(calls []ProviderCall, c ProviderCall) []ProviderCall {
+	for _, existing := range calls {
+		if existing.key() == c.key() {
+			return calls
+		}
+	}
+	return append(calls, c)
+}
+
+// SummaryCache memoises summaries of functions keyed by the function object.
+// Recursive functions are solved by strongly connected components of the call graph (Tarjan):
+// while a component is analyzed, calls inside it get the summaries found so far,
+// then all functions of the component are analyzed again until their summaries stop growing
+type SummaryCache[F comparable] struct {
+	summaries map[F]*FuncErrors
+	states    map[F]*sccState
+	stack     []F // Functions of the components not finished yet
+	path      []F // Functions being analyzed, the last one is the caller
+	next      int
+}
+
+type sccState struct {
+	index, low int
+	onStack    bool
+	recursive  bool // Called while being analyzed
+	analyze    func(fe *FuncErrors)
+}
+
+func NewSummaryCache[F comparable]() *SummaryCache[F] {
+	return &SummaryCache[F]{
+		summaries: make(map[F]*FuncErrors),
+		states:    make(map[F]*sccState),
+	}
+}
+
+// Summary returns the summary of the function, analyzing it on the first call
+func (c *SummaryCache[F]) Summary(fn F, analyze func(fe *FuncErrors)) *FuncErrors {
+	if fe, ok := c.summaries[fn]; ok {
+		if st := c.states[fn]; st.onStack {
+			// back edge into the component being analyzed
+			st.recursive = true
+			c.lowlink(st.index)
+		}
+		return fe
+	}
+
+	fe := &FuncErrors{}
+	st := &sccState{index: c.next, low: c.next, onStack: true, analyze: analyze}
+	c.next++
+	c.summaries[fn] = fe
+	c.states[fn] = st
+	c.stack = append(c.stack, fn)
+	c.path = append(c.path, fn)
+	analyze(fe)
+	c.path = c.path[:len(c.path)-1]
+	c.lowlink(st.low)
+	if st.low != st.index {
+		return fe // the root of the component solves it
+	}
+
+	var scc []F
+	recursive := false
+	for {
+		m := c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+		scc = append(scc, m)
+		recursive = recursive || c.states[m].recursive
+		if m == fn {
+			break
+		}
+	}
+	for changed := recursive; changed; {
+		changed = false
+		for _, m := range scc {
+			fresh := &FuncErrors{}
+			c.states[m].analyze(fresh)
+			if c.summaries[m].merge(fresh) {
+				changed = true
+			}
+		}
+	}
+	for _, m := range scc {
+		c.states[m].onStack = false
+	}
+	return fe
+}
+
+// lowlink lowers the lowlink of the caller
+func (c *SummaryCache[F]) lowlink(low int) {
+	if len(c.path) == 0 {
+		return
+	}
+	st := c.states[c.path[len(c.path)-1]]
+	st.low = min(st.low, low)
+}
@@ -0,0 +1,51 @@
+package collecterrs
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// analyzeFlow analyzes the fixture module testdata/flow
+func analyzeFlow(t *testing.T, mode string) *Report {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.ModuleDir = "testdata/flow"
+	cfg.Layers = []Layer{{Name: "usecase", Package: "usecase"}}
+	cfg.Externals = nil
+	cfg.Mode = mode
+	report, err := NewUsecaseAnalysis().Analyze(cfg, false)
+	if err != nil {
+		t.Fatalf("%s: %v", mode, err)
+	}
+	return report
+}
+
+// reportCodes returns the sorted codes of the usecase of the fixture
+func reportCodes(report *Report, usecase string) string {
+	var codes []string
+	for _, e := range report.Services["flow"][usecase] {
+		codes = append(codes, e.Code)
+	}
+	slices.Sort(codes)
+	return strings.Join(codes, " ")
+}
+
+// TestSummaryCache checks the summaries of recursive helpers, which are solved by the fixpoint,
+// and that same-named methods of different types have their own summaries
+func TestSummaryCache(t *testing.T) {
+	for _, mode := range []string{ModeAST, ModeSSA} {
+		report := analyzeFlow(t, mode)
+		for _, tt := range []struct{ usecase, want string }{
+			{"Countdown", "TooDeep"},
+			{"Even", "Even Odd"},
+			{"Odd", "Even Odd"},
+			{"User", "UserGet"},
+			{"Order", "OrderGet"},
+		} {
+			if got := reportCodes(report, tt.usecase); got != tt.want {
+				t.Errorf("%s: %s: got %q, want %q", mode, tt.usecase, got, tt.want)
+			}
+		}
+	}
+}
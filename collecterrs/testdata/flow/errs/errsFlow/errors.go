@@ -0,0 +1,19 @@
+package errsFlow
+
+import "example.com/flow/pkg/errs"
+
+var (
+	TooDeepError      = errs.NewServiceError("TooDeep", errs.TypeUserRelatedError, "Recursion is too deep")
+	EvenError         = errs.NewServiceError("Even", errs.TypeUserRelatedError, "Stopped at an even number")
+	OddError          = errs.NewServiceError("Odd", errs.TypeUserRelatedError, "Stopped at an odd number")
+	UserGetError      = errs.NewServiceError("UserGet", errs.TypeUserRelatedError, "Failed to get the user")
+	OrderGetError     = errs.NewServiceError("OrderGet", errs.TypeUserRelatedError, "Failed to get the order")
+	LeftError         = errs.NewServiceError("Left", errs.TypeUserRelatedError, "Left branch")
+	RightError        = errs.NewServiceError("Right", errs.TypeUserRelatedError, "Right branch")
+	OverwrittenError  = errs.NewServiceError("Overwritten", errs.TypeUserRelatedError, "Overwritten before the return")
+	ReassignedError   = errs.NewServiceError("Reassigned", errs.TypeUserRelatedError, "Assigned last")
+	OuterError        = errs.NewServiceError("Outer", errs.TypeUserRelatedError, "Declared in the function")
+	ShadowedError     = errs.NewServiceError("Shadowed", errs.TypeUserRelatedError, "Declared in the block")
+	UserNotFoundError = errs.NewServiceError("UserNotFound", errs.TypeUserRelatedError, "User not found")
+	UserBlockedError  = errs.NewServiceError("UserBlocked", errs.TypeUserRelatedError, "User is blocked")
+)
@@ -0,0 +1,32 @@
+package errs
+
+import "errors"
+
+type Type string
+
+const TypeUserRelatedError Type = "USER_RELATED_ERROR"
+
+type ServiceError struct {
+	Code        string
+	Description string
+	Type        Type
+	Details     map[string]string
+}
+
+func (r ServiceError) Error() string {
+	return r.Code
+}
+
+func (r ServiceError) WithDetails(details map[string]string) ServiceError {
+	r.Details = details
+	return r
+}
+
+func (r ServiceError) Is(err error) bool {
+	var target ServiceError
+	return errors.As(err, &target) && r.Code == target.Code
+}
+
+func NewServiceError(code string, t Type, description string) ServiceError {
+	return ServiceError{Code: code, Type: t, Description: description}
+}
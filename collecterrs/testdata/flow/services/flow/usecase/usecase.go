@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"errors"
+
+	"example.com/flow/errs/errsFlow"
+)
+
+var _ Flow = (*flowImpl)(nil)
+
+type Flow interface {
+	Countdown(n int) error
+	Even(n int) error
+	Odd(n int) error
+	User() error
+	Order() error
+	Phi(n int) error
+	Reassign() error
+	Shadow(n int) error
+	Later(n int) error
+	HandledOnOnePath(n int) error
+}
+
+type flowImpl struct {
+	users  userStore
+	orders orderStore
+}
+
+func (u *flowImpl) Countdown(n int) error {
+	return countdown(n)
+}
+
+// countdown is a recursive helper
+func countdown(n int) error {
+	if n > 100 {
+		return errsFlow.TooDeepError
+	}
+	if n == 0 {
+		return nil
+	}
+	return countdown(n - 1)
+}
+
+func (u *flowImpl) Even(n int) error {
+	return even(n)
+}
+
+func (u *flowImpl) Odd(n int) error {
+	return odd(n)
+}
+
+// even and odd are mutually recursive helpers
+func even(n int) error {
+	if n == 0 {
+		return errsFlow.EvenError
+	}
+	return odd(n - 1)
+}
+
+func odd(n int) error {
+	if n == 0 {
+		return errsFlow.OddError
+	}
+	return even(n - 1)
+}
+
+// userStore and orderStore have methods with the same name
+type userStore struct{}
+
+func (userStore) Get() error {
+	return errsFlow.UserGetError
+}
+
+type orderStore struct{}
+
+func (orderStore) Get() error {
+	return errsFlow.OrderGetError
+}
+
+func (u *flowImpl) User() error {
+	return u.users.Get()
+}
+
+func (u *flowImpl) Order() error {
+	return u.orders.Get()
+}
+
+func (u *flowImpl) Phi(n int) error {
+	var err error
+	if n > 0 {
+		err = errsFlow.LeftError
+	} else {
+		err = errsFlow.RightError
+	}
+	return err
+}
+
+func (u *flowImpl) Reassign() error {
+	err := errsFlow.OverwrittenError
+	err = errsFlow.ReassignedError
+	return err
+}
+
+func (u *flowImpl) Shadow(n int) error {
+	err := errsFlow.OuterError
+	if n > 0 {
+		err := errsFlow.ShadowedError
+		_ = err
+	}
+	return err
+}
+
+func (u *flowImpl) find(n int) error {
+	if n == 0 {
+		return errsFlow.UserNotFoundError
+	}
+	if n < 0 {
+		return errsFlow.UserBlockedError
+	}
+	return nil
+}
+
+// Later continues on UserNotFound and returns it later
+func (u *flowImpl) Later(n int) error {
+	err := u.find(n)
+	if err != nil && !errors.Is(err, errsFlow.UserNotFoundError) {
+		return err
+	}
+	return err
+}
+
+// HandledOnOnePath returns the error of find through two branches, only one of them handles UserNotFound
+func (u *flowImpl) HandledOnOnePath(n int) error {
+	err := u.find(n)
+	if n > 10 {
+		if errors.Is(err, errsFlow.UserNotFoundError) {
+			return nil
+		}
+		if n > 20 {
+			err = errsFlow.LeftError
+		}
+	} else {
+		n--
+	}
+	return err
+}
@@ -4,17 +4,140 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"go/types"
 	"strings"
+
+	"golang.org/x/tools/go/packages"
 )
 
+// ErrorResolver resolves expressions to named errors using type information.
+// A named error is any package-level variable of type errs.ServiceError,
+// regardless of its name, the package it is declared in or the import alias
+type ErrorResolver struct {
+	info        *types.Info
+	errsPkgPath string // Import path of the package declaring ServiceError
+	registry    *ErrorRegistry
+	wrappers    *WrapperIndex // Unwrapping is disabled if nil
+}
+
+func NewErrorResolver(info *types.Info, errsPkgPath string, registry *ErrorRegistry, wrappers *WrapperIndex) *ErrorResolver {
+	return &ErrorResolver{
+		info:        info,
+		errsPkgPath: errsPkgPath,
+		registry:    registry,
+		wrappers:    wrappers,
+	}
+}
+
+// Unwrap returns the expressions of the errors wrapped by the expression, or the expression itself
+func (r *ErrorResolver) Unwrap(expr ast.Expr) []ast.Expr {
+	if r.wrappers == nil || r.info == nil {
+		return []ast.Expr{ast.Unparen(expr)}
+	}
+	return r.wrappers.Unwrap(expr, r.info)
+}
+
+// Object returns the object an identifier or a selector refers to
+func (r *ErrorResolver) Object(expr ast.Expr) types.Object {
+	if r.info == nil {
+		return nil
+	}
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		if obj := r.info.Uses[e]; obj != nil {
+			return obj
+		}
+		return r.info.Defs[e]
+	case *ast.SelectorExpr:
+		return r.info.Uses[e.Sel]
+	}
+	return nil
+}
+
+// NamedError returns the package-level ServiceError variable the expression refers to
+func (r *ErrorResolver) NamedError(expr ast.Expr) *types.Var {
+	v, ok := r.Object(expr).(*types.Var)
+	if !ok || v.Pkg() == nil || v.Pkg().Scope().Lookup(v.Name()) != v {
+		return nil
+	}
+	if !r.isServiceError(v.Type()) {
+		return nil
+	}
+	return v
+}
+
+// IsServiceErrorMethod checks that the call is a method of ServiceError, for example WithDetails or Is
+func (r *ErrorResolver) IsServiceErrorMethod(call *ast.CallExpr, name string) bool {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	fn, ok := r.Object(sel).(*types.Func)
+	if !ok {
+		return false
+	}
+	recv := fn.Type().(*types.Signature).Recv()
+	return recv != nil && r.isServiceError(recv.Type())
+}
+
+// IsFunc checks that the call is a call of the package function pkgPath.name
+func (r *ErrorResolver) IsFunc(call *ast.CallExpr, pkgPath, name string) bool {
+	fn, ok := r.Object(call.Fun).(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == pkgPath && fn.Name() == name &&
+		fn.Type().(*types.Signature).Recv() == nil
+}
+
+// Error returns the named error for the package-level variable with its declaration.
+// If the declaration is not found (for example the code is not a constant), the variable name is used as code
+func (r *ErrorResolver) Error(v *types.Var) NamedError {
+	if d := r.registry.Lookup(v); d != nil {
+		return NamedError{Code: d.Code, Decl: d}
+	}
+	return NamedError{Code: v.Name()}
+}
+
+func (r *ErrorResolver) isServiceError(t types.Type) bool {
+	if ptr, ok := types.Unalias(t).(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	named, ok := types.Unalias(t).(*types.Named)
+	if !ok || named.Obj().Name() != "ServiceError" || named.Obj().Pkg() == nil {
+		return false
+	}
+	return r.errsPkgPath == "" || named.Obj().Pkg().Path() == r.errsPkgPath
+}
+
+// NamedError is a named error found in the analyzed code
+type NamedError struct {
+	Code    string
+	Details []DetailField
+	Decl    *ErrorDecl // Declaration from NewServiceError, nil if not found
+	Trace   []Site     // Sites from the analyzed function down to the return of the error
+}
+
+func (e NamedError) String() string {
+	if len(e.Details) > 0 {
+		return fmt.Sprintf("%s (%s)", e.Code, detailsString(e.Details))
+	}
+	return e.Code
+}
+
+// at returns the error passing through the site before its trace
+func (e NamedError) at(sites ...Site) NamedError {
+	e.Trace = prependSite(e.Trace, sites...)
+	return e
+}
+
 // ErrorVarTracker is responsible for finding returned errors that were saved in a variable
 type ErrorVarTracker struct {
-	errorVars map[string]string // Variable name → error code
+	resolver  *ErrorResolver
+	errorVars map[types.Object][]NamedError // Variable → errors
 }
 
-func NewErrorVarTracker() *ErrorVarTracker {
+func NewErrorVarTracker(resolver *ErrorResolver) *ErrorVarTracker {
 	return &ErrorVarTracker{
-		errorVars: make(map[string]string),
+		resolver:  resolver,
+		errorVars: make(map[types.Object][]NamedError),
 	}
 }
 
@@ -22,48 +145,73 @@ func (et *ErrorVarTracker) Track(n ast.Node) {
 	switch stmt := n.(type) {
 	case *ast.AssignStmt:
 		for _, expr := range stmt.Rhs {
-			code := et.getErrorCode(expr)
-			if code != "" {
+			if namedErrs := et.getErrors(expr); len(namedErrs) > 0 {
 				for _, lhs := range stmt.Lhs {
-					if ident, ok := lhs.(*ast.Ident); ok {
-						et.errorVars[ident.Name] = code
+					if obj := et.resolver.Object(lhs); obj != nil {
+						et.errorVars[obj] = namedErrs
 					}
 				}
 			}
 		}
+	case *ast.ValueSpec:
+		for i, expr := range stmt.Values {
+			if namedErrs := et.getErrors(expr); len(namedErrs) > 0 && i < len(stmt.Names) {
+				if obj := et.resolver.Object(stmt.Names[i]); obj != nil {
+					et.errorVars[obj] = namedErrs
+				}
+			}
+		}
 	}
 }
 
-func (et *ErrorVarTracker) getErrorCode(expr ast.Expr) string {
-	switch e := expr.(type) {
-	case *ast.SelectorExpr:
-		if strings.HasSuffix(e.Sel.Name, "Error") {
-			if ident, ok := e.X.(*ast.Ident); ok && strings.HasPrefix(ident.Name, "errs") {
-				return strings.TrimSuffix(e.Sel.Name, "Error")
-			}
+// getErrors returns named errors of the expression, including the errors it wraps:
+// fmt.Errorf("ctx: %w", errsUsers.UserBlockedError)
+func (et *ErrorVarTracker) getErrors(expr ast.Expr) []NamedError {
+	var namedErrs []NamedError
+	for _, leaf := range et.resolver.Unwrap(expr) {
+		namedErrs = append(namedErrs, et.getError(leaf)...)
+	}
+	return namedErrs
+}
+
+func (et *ErrorVarTracker) getError(expr ast.Expr) []NamedError {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident, *ast.SelectorExpr:
+		if v := et.resolver.NamedError(e); v != nil {
+			return []NamedError{et.resolver.Error(v)}
 		}
-		// Recursively check nested expressions
-		return et.getErrorCode(e.X)
+		// Return errors if the variable is already being tracked
+		if obj := et.resolver.Object(e); obj != nil {
+			return et.errorVars[obj]
+		}
+		return nil
 	case *ast.CallExpr:
-		if sel, ok := e.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "WithDetails" {
-			// Extract code from the base error before WithDetails
-			code := et.getErrorCode(sel.X)
-			if len(e.Args) > 0 {
-				code = fmt.Sprintf("%s (%s)", code, strings.Join(extractMapKeys(e.Args[0]), ","))
+		// Inline declaration errs.NewServiceError("Code", ...)
+		if isNewServiceErrorCall(e, et.resolver.info) && len(e.Args) > 0 {
+			if code, ok := constString(e.Args[0], et.resolver.info); ok {
+				return []NamedError{{Code: code, Decl: et.resolver.registry.LookupCode(code)}}
 			}
-			return code
+			return nil
 		}
-		return ""
-	case *ast.Ident:
-		// Return code if the variable is already being tracked
-		return et.errorVars[e.Name]
+		if et.resolver.IsServiceErrorMethod(e, "WithDetails") {
+			// Extract errors from the base error before WithDetails
+			var namedErrs []NamedError
+			for _, namedErr := range et.getErrors(e.Fun.(*ast.SelectorExpr).X) {
+				if len(e.Args) > 0 {
+					namedErr.Details = extractMapKeys(e.Args[0])
+				}
+				namedErrs = append(namedErrs, namedErr)
+			}
+			return namedErrs
+		}
+		return nil
 	default:
-		return ""
+		return nil
 	}
 }
 
 // Extracts keys from map[string]string literal for WithDetails errors
-func extractMapKeys(expr ast.Expr) []string {
+func extractMapKeys(expr ast.Expr) []DetailField {
 	lit, ok := expr.(*ast.CompositeLit)
 	if !ok {
 		return nil
@@ -87,7 +235,7 @@ func extractMapKeys(expr ast.Expr) []string {
 		return nil
 	}
 
-	var params []string
+	var params []DetailField
 	for _, elt := range lit.Elts {
 		kv, ok := elt.(*ast.KeyValueExpr)
 		if !ok {
@@ -106,160 +254,340 @@ func extractMapKeys(expr ast.Expr) []string {
 		}
 
 		if keyName != "" {
-			params = append(params, fmt.Sprintf("%s:%s", keyName, valueType))
+			params = append(params, DetailField{Key: keyName, Type: valueType})
 		}
 	}
 	return params
 }
 
 // ErrorHandler is responsible for checking returned errors
-// If there is error handling in the code via errors.Is, it marks it
+// If there is error handling in the code via errors.Is, errors.As or switch, it marks it
 // so that the analyzer can later remove it from the returned errors
 type ErrorHandler struct {
-	handledErrors map[string]bool // Codes of handled errors
+	errVar        types.Object          // Checked error variable, any variable if nil
+	asTargets     map[types.Object]bool // ServiceError variables filled by errors.As from the checked error
+	handledErrors map[string]token.Pos  // Codes of handled errors → position of the check
 }
 
-func NewErrorHandler() *ErrorHandler {
+func NewErrorHandler(errVar types.Object) *ErrorHandler {
 	return &ErrorHandler{
-		handledErrors: make(map[string]bool),
+		errVar:        errVar,
+		asTargets:     make(map[types.Object]bool),
+		handledErrors: make(map[string]token.Pos),
 	}
 }
 
 func (eh *ErrorHandler) Inspect(node ast.Node, errtracker *ErrorVarTracker) {
-	if stmt, ok := node.(*ast.IfStmt); ok {
-		// Handle combined conditions with logical operators
-		if binExpr, ok := stmt.Cond.(*ast.BinaryExpr); ok {
-			// Handle pattern: err != nil && !errors.Is(err, errsUsers.UserNotFoundError)
-			if binExpr.Op == token.LAND {
-				// Check if the right side is a unary expression with '!' operator
-				if unaryExpr, ok := binExpr.Y.(*ast.UnaryExpr); ok && unaryExpr.Op == token.NOT {
-					// Check if the operand is an errors.Is call
-					if call, ok := unaryExpr.X.(*ast.CallExpr); ok && isErrorsIsCall(call) {
-						if len(call.Args) < 2 {
-							return
-						}
-						// Second argument of errors.Is is target
-						if targetCall, ok := call.Args[1].(*ast.SelectorExpr); ok {
-							if strings.HasSuffix(targetCall.Sel.Name, "Error") {
-								if ident, ok := targetCall.X.(*ast.Ident); ok && strings.HasPrefix(ident.Name, "errs") {
-									eh.handledErrors[strings.TrimSuffix(targetCall.Sel.Name, "Error")] = true
-								}
-							}
-						}
-
-						// If target is a variable (for example, err)
-						if targetIdent, ok := call.Args[1].(*ast.Ident); ok {
-							if code, exists := errtracker.errorVars[targetIdent.Name]; exists {
-								eh.handledErrors[code] = true
-							}
-						}
-					}
-				}
+	switch stmt := node.(type) {
+	case *ast.CallExpr:
+		eh.inspectAsCall(stmt, errtracker.resolver)
+	case *ast.IfStmt:
+		eh.inspectCond(stmt.Cond, errtracker)
+	case *ast.SwitchStmt:
+		eh.inspectSwitch(stmt, errtracker)
+	}
+}
+
+// inspectCond marks errors checked by the condition:
+//
+//	errors.Is(err, X)
+//	err != nil && !errors.Is(err, X)
+//	errors.Is(err, X) || errsX.Y.Is(err)
+//	serviceErr.Code == errsX.Y.Code
+func (eh *ErrorHandler) inspectCond(cond ast.Expr, errtracker *ErrorVarTracker) {
+	switch e := ast.Unparen(cond).(type) {
+	case *ast.BinaryExpr:
+		switch e.Op {
+		case token.LAND, token.LOR:
+			eh.inspectCond(e.X, errtracker)
+			eh.inspectCond(e.Y, errtracker)
+		case token.EQL, token.NEQ:
+			eh.inspectComparison(e.X, e.Y, errtracker)
+			eh.inspectComparison(e.Y, e.X, errtracker)
+		}
+	case *ast.UnaryExpr:
+		if e.Op == token.NOT {
+			eh.inspectCond(e.X, errtracker)
+		}
+	case *ast.CallExpr:
+		// errors.As goes before the checks of its target: errors.As(err, &serviceErr) && serviceErr.Code == ...
+		eh.inspectAsCall(e, errtracker.resolver)
+		eh.inspectIsCall(e, errtracker)
+	}
+}
+
+// inspectSwitch marks errors of the cases:
+//
+//	switch { case errors.Is(err, X): }
+//	switch err { case errsX.Y: }
+//	switch serviceErr.Code { case errsX.Y.Code, "Code": }
+func (eh *ErrorHandler) inspectSwitch(stmt *ast.SwitchStmt, errtracker *ErrorVarTracker) {
+	for _, clause := range stmt.Body.List {
+		cc, ok := clause.(*ast.CaseClause)
+		if !ok {
+			continue
+		}
+		for _, expr := range cc.List {
+			if stmt.Tag == nil {
+				eh.inspectCond(expr, errtracker)
+			} else {
+				eh.inspectComparison(stmt.Tag, expr, errtracker)
+			}
+		}
+	}
+}
+
+// inspectComparison marks the error compared with the checked error or with the code of an errors.As target
+func (eh *ErrorHandler) inspectComparison(checked, value ast.Expr, errtracker *ErrorVarTracker) {
+	resolver := errtracker.resolver
+	if eh.isCheckedErr(checked, resolver) {
+		for _, namedErr := range errtracker.getErrors(value) {
+			eh.handle(namedErr.Code, value.Pos())
+		}
+		return
+	}
+
+	sel, ok := ast.Unparen(checked).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Code" || !eh.asTargets[resolver.Object(sel.X)] {
+		return
+	}
+	// errsX.Y.Code or the code itself
+	if valueSel, ok := ast.Unparen(value).(*ast.SelectorExpr); ok && valueSel.Sel.Name == "Code" {
+		if namedErrs := errtracker.getErrors(valueSel.X); len(namedErrs) > 0 {
+			for _, namedErr := range namedErrs {
+				eh.handle(namedErr.Code, value.Pos())
 			}
+			return
+		}
+	}
+	if code, ok := constString(value, resolver.info); ok {
+		eh.handle(code, value.Pos())
+	}
+}
+
+// handle marks the error as handled, the first check is kept as its position
+func (eh *ErrorHandler) handle(code string, pos token.Pos) {
+	if _, ok := eh.handledErrors[code]; !ok {
+		eh.handledErrors[code] = pos
+	}
+}
+
+// inspectAsCall remembers the ServiceError variable filled from the checked error by errors.As(err, &serviceErr)
+func (eh *ErrorHandler) inspectAsCall(call *ast.CallExpr, resolver *ErrorResolver) {
+	if !resolver.IsFunc(call, "errors", "As") || len(call.Args) < 2 || !eh.isCheckedErr(call.Args[0], resolver) {
+		return
+	}
+	target := ast.Unparen(call.Args[1])
+	if unary, ok := target.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+		target = unary.X
+	}
+	if obj := resolver.Object(target); obj != nil && resolver.isServiceError(obj.Type()) {
+		eh.asTargets[obj] = true
+	}
+}
+
+// isCheckedErr checks that the expression is the checked error variable
+func (eh *ErrorHandler) isCheckedErr(expr ast.Expr, resolver *ErrorResolver) bool {
+	obj := resolver.Object(expr)
+	if obj == nil {
+		return false
+	}
+	if eh.errVar != nil {
+		return obj == eh.errVar
+	}
+	_, isVar := obj.(*types.Var)
+	return isVar && types.Identical(obj.Type(), errorType)
+}
+
+// inspectIsCall marks the target of errors.Is or of the custom ServiceError.Is as handled
+func (eh *ErrorHandler) inspectIsCall(call *ast.CallExpr, errtracker *ErrorVarTracker) {
+	resolver := errtracker.resolver
+	var checked, target ast.Expr
+	switch {
+	case isErrorsIsCall(call, resolver):
+		// Second argument of errors.Is is target
+		if len(call.Args) < 2 {
+			return
+		}
+		checked, target = call.Args[0], call.Args[1]
+	case isCustomErrorIsCall(call, resolver):
+		// handle custom ServiceError.Is(err)
+		if len(call.Args) < 1 {
+			return
+		}
+		checked, target = call.Args[0], call.Fun.(*ast.SelectorExpr).X
+	default:
+		return
+	}
+	if eh.errVar != nil && !eh.isCheckedErr(checked, resolver) {
+		return
+	}
+
+	// If target is a variable (for example, err)
+	if obj := resolver.Object(target); obj != nil {
+		for _, namedErr := range errtracker.errorVars[obj] {
+			eh.handle(namedErr.Code, call.Pos())
+		}
+	}
+
+	if v := resolver.NamedError(target); v != nil {
+		eh.handle(resolver.Error(v).Code, call.Pos())
+	}
+}
+
+// callSiteHandlers finds errors handled right after each call.
+// The error variable assigned by the call is followed to the next if or switch statement that checks it,
+// checks inside that statement exclude errors of this call only:
+//
+//	resp, err := u.Providers.Otp.ValidateCode(ctx, otpReq)
+//	if err != nil {
+//		if errsOtp.MaxCodeChecksExceededError.Is(err) {
+//			...
+func callSiteHandlers(body *ast.BlockStmt, errtracker *ErrorVarTracker) map[*ast.CallExpr]map[string]token.Pos {
+	handlers := make(map[*ast.CallExpr]map[string]token.Pos)
+	if body == nil {
+		return handlers
+	}
+	resolver := errtracker.resolver
+
+	handledIn := func(check ast.Stmt, errVar types.Object) map[string]token.Pos {
+		eh := NewErrorHandler(errVar)
+		ast.Inspect(check, func(n ast.Node) bool {
+			eh.Inspect(n, errtracker)
+			return true
+		})
+		return eh.handledErrors
+	}
+
+	ast.Inspect(body, func(n ast.Node) bool {
+		var list []ast.Stmt
+		switch b := n.(type) {
+		case *ast.BlockStmt:
+			list = b.List
+		case *ast.CaseClause:
+			list = b.Body
+		case *ast.CommClause:
+			list = b.Body
+		default:
+			return true
 		}
 
-		// Handle errors.Is(err, target)
-		if call, ok := stmt.Cond.(*ast.CallExpr); ok {
-			if isErrorsIsCall(call) {
-				if len(call.Args) < 2 {
-					return
+		for i, stmt := range list {
+			switch st := stmt.(type) {
+			case *ast.AssignStmt:
+				call, errVar := assignedCall(st, resolver)
+				if call == nil {
+					continue
 				}
-				// Second argument of errors.Is is target
-				// If target is a function call (for example, usersErrs.TokenRefreshForbiddenError())
-				if targetCall, ok := call.Args[1].(*ast.SelectorExpr); ok {
-					if strings.HasSuffix(targetCall.Sel.Name, "Error") {
-						if ident, ok := targetCall.X.(*ast.Ident); ok && strings.HasPrefix(ident.Name, "errs") {
-							eh.handledErrors[strings.TrimSuffix(targetCall.Sel.Name, "Error")] = true
-						}
-					}
+				if check := nextErrCheck(list[i+1:], errVar, resolver); check != nil {
+					handlers[call] = handledIn(check, errVar)
 				}
-
-				// If target is a variable (for example, err)
-				if targetIdent, ok := call.Args[1].(*ast.Ident); ok {
-					if code, exists := errtracker.errorVars[targetIdent.Name]; exists {
-						eh.handledErrors[code] = true
-					}
+			default:
+				// if _, err := call(); err != nil {
+				assign, ok := stmtInit(st).(*ast.AssignStmt)
+				if !ok {
+					continue
 				}
-			} else if isCustomErrorIsCall(call) {
-				// handle custom ServiceError.Is(err)
-				if len(call.Args) < 1 {
-					return
+				call, errVar := assignedCall(assign, resolver)
+				if call != nil && checksErr(st, errVar, resolver) {
+					handlers[call] = handledIn(st, errVar)
 				}
+			}
+		}
+		return true
+	})
+	return handlers
+}
 
-				if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
-					if strings.HasSuffix(sel.Sel.Name, "Is") {
-						if errorSel, ok := sel.X.(*ast.SelectorExpr); ok {
-							if strings.HasSuffix(errorSel.Sel.Name, "Error") {
-								if ident, ok := errorSel.X.(*ast.Ident); ok && strings.HasPrefix(ident.Name, "errs") {
-									errorName := strings.TrimSuffix(errorSel.Sel.Name, "Error")
-									//fmt.Printf("[DEBUG] Found custom error check: %s.%s.Is() - marking as handled\n",
-									//	ident.Name, errorSel.Sel.Name)
-									eh.handledErrors[errorName] = true
-
-									// Also mark the error with details as handled
-									detailsPattern := errorName + " ("
-									for k := range eh.handledErrors {
-										if strings.HasPrefix(k, detailsPattern) {
-											//fmt.Printf("[DEBUG] Also marking as handled with details: %s\n", k)
-											eh.handledErrors[k] = true
-										}
-									}
-
-									// Check full name with package prefix
-									if strings.HasPrefix(ident.Name, "errs") {
-										serviceName := strings.TrimPrefix(ident.Name, "errs")
-										if serviceName != "" {
-											servicePrefix := strings.ToLower(string(serviceName[0])) + serviceName[1:]
-											fullErrorName := servicePrefix + "." + errorName
-											//fmt.Printf("[DEBUG] Also marking as handled with service prefix: %s\n", fullErrorName)
-											eh.handledErrors[fullErrorName] = true
-
-											// Also mark the error with service prefix and details as handled
-											detailsPattern = fullErrorName + " ("
-											for k := range eh.handledErrors {
-												if strings.HasPrefix(k, detailsPattern) {
-													//fmt.Printf("[DEBUG] Also marking as handled with service prefix and details: %s\n", k)
-													eh.handledErrors[k] = true
-												}
-											}
-										}
-									}
-								}
-							}
-						}
-					}
+// assignedCall returns the call of the assignment and the error variable it assigns
+func assignedCall(assign *ast.AssignStmt, resolver *ErrorResolver) (*ast.CallExpr, types.Object) {
+	if len(assign.Rhs) != 1 {
+		return nil, nil
+	}
+	call, ok := ast.Unparen(assign.Rhs[0]).(*ast.CallExpr)
+	if !ok {
+		return nil, nil
+	}
+	for i := len(assign.Lhs) - 1; i >= 0; i-- {
+		obj := resolver.Object(assign.Lhs[i])
+		if obj != nil && types.Identical(obj.Type(), errorType) {
+			return call, obj
+		}
+	}
+	return nil, nil
+}
+
+// nextErrCheck returns the next if or switch statement checking the error variable,
+// nil if the variable is assigned again before it
+func nextErrCheck(stmts []ast.Stmt, errVar types.Object, resolver *ErrorResolver) ast.Stmt {
+	for _, stmt := range stmts {
+		if checksErr(stmt, errVar, resolver) {
+			return stmt
+		}
+		if assign, ok := stmt.(*ast.AssignStmt); ok {
+			for _, lhs := range assign.Lhs {
+				if resolver.Object(lhs) == errVar {
+					return nil
 				}
 			}
 		}
 	}
+	return nil
 }
 
-func isErrorsIsCall(call *ast.CallExpr) bool {
-	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
-		return sel.Sel.Name == "Is" && isErrorsPackage(sel.X)
+// checksErr checks that the condition of the if statement, the switch tag or the switch cases use the error variable
+func checksErr(stmt ast.Stmt, errVar types.Object, resolver *ErrorResolver) bool {
+	switch st := stmt.(type) {
+	case *ast.IfStmt:
+		return refersTo(st.Cond, errVar, resolver)
+	case *ast.SwitchStmt:
+		if st.Tag != nil {
+			return refersTo(st.Tag, errVar, resolver)
+		}
+		for _, clause := range st.Body.List {
+			for _, expr := range clause.(*ast.CaseClause).List {
+				if refersTo(expr, errVar, resolver) {
+					return true
+				}
+			}
+		}
 	}
 	return false
 }
 
-func isErrorsPackage(expr ast.Expr) bool {
-	if ident, ok := expr.(*ast.Ident); ok {
-		return ident.Name == "errors"
+func stmtInit(stmt ast.Stmt) ast.Stmt {
+	switch st := stmt.(type) {
+	case *ast.IfStmt:
+		return st.Init
+	case *ast.SwitchStmt:
+		return st.Init
 	}
-	return false
+	return nil
+}
+
+// refersTo checks that the expression uses the variable
+func refersTo(expr ast.Expr, obj types.Object, resolver *ErrorResolver) bool {
+	found := false
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && resolver.Object(ident) == obj {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func isErrorsIsCall(call *ast.CallExpr, resolver *ErrorResolver) bool {
+	return resolver.IsFunc(call, "errors", "Is")
 }
 
 // isCustomErrorIsCall checks if this is a call to a custom Error.Is method
 // ex: errs.MaxCodeChecksExceededError.Is(err)
-// This is a general solution for any error object ending with "Error" that has an .Is method
-func isCustomErrorIsCall(call *ast.CallExpr) bool {
-	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
-		if sel.Sel.Name == "Is" {
-			if errorSel, ok := sel.X.(*ast.SelectorExpr); ok {
-				return strings.HasSuffix(errorSel.Sel.Name, "Error")
-			}
-		}
-	}
-	return false
+// This is a general solution for any named error, since every ServiceError has an .Is method
+func isCustomErrorIsCall(call *ast.CallExpr, resolver *ErrorResolver) bool {
+	return resolver.IsServiceErrorMethod(call, "Is")
 }
 
 // ProviderCall is responsible for tracking calls to adjacent dependencies through a provider
@@ -267,78 +595,119 @@ func isCustomErrorIsCall(call *ast.CallExpr) bool {
 type ProviderCall struct {
 	Provider string
 	Method   string
+	Client   string         // Proto service the provider is the gRPC client of, empty for other providers
+	Handled  HandledErrors  // Codes of errors handled right after this call
+	Pos      token.Position // Position of the call
+	Trace    []Site         // Calls of the functions of the module from the analyzed function down to this call
+
+	method *types.Func // Called method of the provider field type, the interface method for interfaces
 }
 
 func (p ProviderCall) String() string {
 	return fmt.Sprintf("[%s].%s", p.Provider, p.Method)
 }
 
+// key identifies the call site by the method, the handled errors and the position
+func (p ProviderCall) key() string {
+	return p.String() + " " + strings.Join(sortedKeys(p.Handled), ",") + " " + p.Pos.String()
+}
+
+// WithHandled returns the call with additionally handled errors of the outer call site
+func (p ProviderCall) WithHandled(handled HandledErrors) ProviderCall {
+	if len(handled) == 0 {
+		return p
+	}
+	merged := make(HandledErrors, len(p.Handled)+len(handled))
+	for code, pos := range handled {
+		merged[code] = pos
+	}
+	for code, pos := range p.Handled {
+		merged[code] = pos
+	}
+	p.Handled = merged
+	return p
+}
+
+// at returns the call reached through the site before its trace
+func (p ProviderCall) at(sites ...Site) ProviderCall {
+	p.Trace = prependSite(p.Trace, sites...)
+	return p
+}
+
+// path returns the sites from the analyzed function down to the call itself
+func (p ProviderCall) path() []Site {
+	return prependSite([]Site{{Kind: SiteProvider, Name: p.String(), pos: p.Pos}}, p.Trace...)
+}
+
 type ProviderTracker struct {
-	Calls map[string][]ProviderCall // Variable name → list of calls
+	Calls     map[types.Object][]ProviderCall // Variable → list of calls
+	handlers  map[*ast.CallExpr]HandledErrors // Call → errors handled after it
+	providers *ProviderIndex
+	pkg       *packages.Package // Package of the analyzed function
 }
 
-func NewProviderTracker() *ProviderTracker {
+func NewProviderTracker(providers *ProviderIndex, pkg *packages.Package) *ProviderTracker {
 	return &ProviderTracker{
-		Calls: make(map[string][]ProviderCall),
+		Calls:     make(map[types.Object][]ProviderCall),
+		handlers:  make(map[*ast.CallExpr]HandledErrors),
+		providers: providers,
+		pkg:       pkg,
+	}
+}
+
+// AddHandlers saves errors handled after the calls of the analyzed function
+func (t *ProviderTracker) AddHandlers(handlers map[*ast.CallExpr]map[string]token.Pos) {
+	for call, handled := range handlers {
+		positions := make(HandledErrors, len(handled))
+		for code, pos := range handled {
+			positions[code] = t.pkg.Fset.Position(pos)
+		}
+		t.handlers[call] = positions
 	}
 }
 
+// Call returns the provider call with errors handled at this call site
+func (t *ProviderTracker) Call(call *ast.CallExpr) (ProviderCall, bool) {
+	providerCall, ok := t.providers.Call(call, t.pkg.TypesInfo, t.pkg.Types)
+	if !ok {
+		return ProviderCall{}, false
+	}
+	providerCall.Handled = t.handlers[call]
+	providerCall.Pos = t.pkg.Fset.Position(call.Pos())
+	return providerCall, true
+}
+
 func (t *ProviderTracker) Track(node ast.Node, ua *UsecaseAnalysis) {
 	switch stmt := node.(type) {
 	case *ast.AssignStmt:
 		for _, expr := range stmt.Rhs {
 			if call, ok := expr.(*ast.CallExpr); ok {
 				// Processing function calls that return providers
-				funcName := getFuncName(call)
-				if funcName != "" {
-					if providers, exists := ua.returnedProviders[funcName]; exists {
-						for _, lhs := range stmt.Lhs {
-							if ident, ok := lhs.(*ast.Ident); ok {
-								t.Calls[ident.Name] = append(t.Calls[ident.Name], providers...)
-							}
-						}
-					}
+				for _, provider := range ua.returnedCalls(call, t.pkg) {
+					provider = provider.WithHandled(t.handlers[call])
+					t.assign(stmt.Lhs, provider)
 				}
 				// Direct provider calls
-				if provider, method, ok := extractProviderMethod(call); ok {
-					for _, lhs := range stmt.Lhs {
-						if ident, ok := lhs.(*ast.Ident); ok {
-							t.Calls[ident.Name] = append(t.Calls[ident.Name], ProviderCall{
-								Provider: provider,
-								Method:   method,
-							})
-						}
-					}
+				if providerCall, ok := t.Call(call); ok {
+					t.assign(stmt.Lhs, providerCall)
 				}
 			}
 		}
 	}
 }
 
-func getFuncName(call *ast.CallExpr) string {
-	switch fn := call.Fun.(type) {
-	case *ast.Ident:
-		return fn.Name
-	case *ast.SelectorExpr:
-		return fmt.Sprintf("%s.%s", exprToString(fn.X), fn.Sel.Name)
-	default:
-		return ""
-	}
-}
-
-func extractProviderMethod(call *ast.CallExpr) (string, string, bool) {
-	sel, ok := call.Fun.(*ast.SelectorExpr)
-	if !ok {
-		return "", "", false
-	}
-
-	// Expecting chain: u.Providers.<Provider>.<Method>
-	if xSel, ok := sel.X.(*ast.SelectorExpr); ok {
-		if providersSel, ok := xSel.X.(*ast.SelectorExpr); ok {
-			if providersSel.Sel.Name == "Providers" {
-				return xSel.Sel.Name, sel.Sel.Name, true
+// assign saves the call as the origin of the assigned variables
+func (t *ProviderTracker) assign(lhs []ast.Expr, call ProviderCall) {
+	for _, expr := range lhs {
+		if ident, ok := expr.(*ast.Ident); ok {
+			if obj := t.pkg.TypesInfo.ObjectOf(ident); obj != nil {
+				t.Calls[obj] = append(t.Calls[obj], call)
 			}
 		}
 	}
-	return "", "", false
+}
+
+// VarCalls returns the provider calls assigned to the variable
+func (t *ProviderTracker) VarCalls(ident *ast.Ident) []ProviderCall {
+	return t.Calls[t.pkg.TypesInfo.ObjectOf(ident)]
 }
@@ -0,0 +1,88 @@
+package collecterrs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a temporary git worktree with the tree of a revision, detached from any branch
+type Worktree struct {
+	Dir  string // Directory of the worktree
+	top  string // Top level directory of the repository the worktree is added to
+	repo string
+}
+
+// NewWorktree checks out the ref of the repository containing dir into a temporary worktree
+func NewWorktree(dir, ref string) (*Worktree, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	commit, err := git(dir, "rev-parse", "--verify", ref+"^{commit}")
+	if err != nil {
+		return nil, fmt.Errorf("unknown revision %q: %w", ref, err)
+	}
+
+	tmp, err := os.MkdirTemp("", "collecterrs-")
+	if err != nil {
+		return nil, err
+	}
+	w := &Worktree{Dir: filepath.Join(tmp, shortCommit(commit)), top: top, repo: dir}
+	if _, err := git(dir, "worktree", "add", "--detach", w.Dir, commit); err != nil {
+		os.RemoveAll(tmp)
+		return nil, err
+	}
+	return w, nil
+}
+
+// Path maps the path inside the current checkout to the same path inside the worktree
+func (w *Worktree) Path(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	top, err := filepath.EvalSymlinks(w.top)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = resolved
+	}
+	rel, err := filepath.Rel(top, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the repository %s", path, w.top)
+	}
+	return filepath.Join(w.Dir, rel), nil
+}
+
+// Remove removes the worktree and its temporary directory
+func (w *Worktree) Remove() error {
+	_, err := git(w.repo, "worktree", "remove", "--force", w.Dir)
+	if rmErr := os.RemoveAll(filepath.Dir(w.Dir)); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// git runs the git command in the directory and returns its trimmed output
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+func shortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
@@ -0,0 +1,69 @@
+package collecterrs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestWorktree(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"config", "user.name", "test"},
+		{"config", "user.email", "test@example.com"},
+	} {
+		if _, err := git(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	file := filepath.Join(repo, "module", "errors.json")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte("base"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", "base"}} {
+		if _, err := git(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(file, []byte("head"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	wt, err := NewWorktree(filepath.Dir(file), "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path, err := wt.Path(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "base" {
+		t.Errorf("worktree has %q, want the committed content", content)
+	}
+	if _, err := wt.Path(t.TempDir()); err == nil {
+		t.Error("a path outside of the repository is mapped into the worktree")
+	}
+
+	if err := wt.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wt.Dir); !os.IsNotExist(err) {
+		t.Errorf("worktree %s is not removed: %v", wt.Dir, err)
+	}
+
+	if _, err := NewWorktree(repo, "no-such-ref"); err == nil {
+		t.Error("unknown revision is checked out")
+	}
+}
@@ -0,0 +1,230 @@
+package collecterrs
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+)
+
+// WrapperIndex finds errors wrapped into other errors, so that they are still named for the callers:
+// fmt.Errorf with %w, errors.Join, values of types with the Unwrap method
+// and functions of the module returning their error parameters
+type WrapperIndex struct {
+	funcs  *FuncIndex
+	params map[*types.Func][]int // Function → indices of the parameters it returns wrapped
+}
+
+func NewWrapperIndex(funcs *FuncIndex) *WrapperIndex {
+	return &WrapperIndex{
+		funcs:  funcs,
+		params: make(map[*types.Func][]int),
+	}
+}
+
+// Unwrap returns the expressions of the errors the expression wraps,
+// or the expression itself if it does not wrap anything
+func (w *WrapperIndex) Unwrap(expr ast.Expr, info *types.Info) []ast.Expr {
+	expr = ast.Unparen(expr)
+	inner, keep := w.wrapped(expr, info)
+	if inner == nil {
+		return []ast.Expr{expr}
+	}
+	var leaves []ast.Expr
+	if keep {
+		leaves = append(leaves, expr)
+	}
+	for _, e := range inner {
+		leaves = append(leaves, w.Unwrap(e, info)...)
+	}
+	return leaves
+}
+
+// wrapped returns the expressions the error expression wraps directly, nil if it is not a wrapper.
+// keep reports that the expression can return its own errors as well, as a wrapper function of the module
+func (w *WrapperIndex) wrapped(expr ast.Expr, info *types.Info) (inner []ast.Expr, keep bool) {
+	switch e := expr.(type) {
+	case *ast.UnaryExpr:
+		// &wrapError{err: err}
+		if lit, ok := ast.Unparen(e.X).(*ast.CompositeLit); ok && e.Op == token.AND {
+			return wrappedFields(lit, info), false
+		}
+	case *ast.CompositeLit:
+		return wrappedFields(e, info), false
+	case *ast.CallExpr:
+		fn := calledFunc(e, info)
+		if fn == nil {
+			return nil, false
+		}
+		switch {
+		case isPkgFunc(fn, "fmt", "Errorf"):
+			inner = []ast.Expr{}
+			if format, ok := constString(e.Args[0], info); ok {
+				for _, i := range wrappedArgs(format) {
+					if i < len(e.Args) {
+						inner = append(inner, e.Args[i])
+					}
+				}
+			}
+			return inner, false
+		case isPkgFunc(fn, "errors", "Join"):
+			return append([]ast.Expr{}, e.Args...), false
+		}
+		params := w.Params(fn)
+		if len(params) == 0 {
+			return nil, false
+		}
+		sig := fn.Type().(*types.Signature)
+		for _, i := range params {
+			if sig.Variadic() && i == sig.Params().Len()-1 && !e.Ellipsis.IsValid() {
+				inner = append(inner, e.Args[min(i, len(e.Args)):]...)
+			} else if i < len(e.Args) {
+				inner = append(inner, e.Args[i])
+			}
+		}
+		return inner, true
+	}
+	return nil, false
+}
+
+// Params returns indices of the parameters the function returns wrapped or as is
+func (w *WrapperIndex) Params(fn *types.Func) []int {
+	if params, ok := w.params[fn]; ok {
+		return params
+	}
+	w.params[fn] = nil // recursive functions
+	d, ok := w.funcs.Lookup(fn)
+	if !ok {
+		return nil
+	}
+	info := d.Pkg.TypesInfo
+
+	sig := fn.Type().(*types.Signature)
+	found := map[int]bool{}
+	ast.Inspect(d.Decl.Body, func(n ast.Node) bool {
+		switch node := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			for _, result := range node.Results {
+				for _, leaf := range w.Unwrap(result, info) {
+					ident, ok := leaf.(*ast.Ident)
+					if !ok {
+						continue
+					}
+					for i := 0; i < sig.Params().Len(); i++ {
+						if info.Uses[ident] == sig.Params().At(i) {
+							found[i] = true
+						}
+					}
+				}
+			}
+		}
+		return true
+	})
+
+	var params []int
+	for i := 0; i < sig.Params().Len(); i++ {
+		if found[i] {
+			params = append(params, i)
+		}
+	}
+	w.params[fn] = params
+	return params
+}
+
+// wrappedFields returns error fields of the composite literal of a type with the Unwrap method
+func wrappedFields(lit *ast.CompositeLit, info *types.Info) []ast.Expr {
+	tv, ok := info.Types[lit]
+	if !ok || !hasUnwrap(tv.Type) {
+		return nil
+	}
+	inner := []ast.Expr{}
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			elt = kv.Value
+		}
+		if t := info.TypeOf(elt); t != nil && types.Implements(t, errorInterface) {
+			inner = append(inner, elt)
+		}
+	}
+	return inner
+}
+
+// hasUnwrap checks that the value of the type, or the pointer to it, has the Unwrap method, as wrapping errors do
+func hasUnwrap(t types.Type) bool {
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(t), true, nil, "Unwrap")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+// wrappedArgs returns indices of the fmt.Errorf arguments formatted with %w, the format is the argument 0
+func wrappedArgs(format string) []int {
+	var indices []int
+	arg := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+		// flags, width, precision and the explicit argument index [n]
+		for i < len(format) {
+			c := format[i]
+			if c == '[' {
+				end := i + 1
+				for end < len(format) && format[end] != ']' {
+					end++
+				}
+				if n, err := strconv.Atoi(format[i+1 : end]); err == nil {
+					arg = n - 1
+				}
+				i = end + 1
+				continue
+			}
+			if c == '*' {
+				arg++
+			}
+			if c == '+' || c == '-' || c == '#' || c == ' ' || c == '0' || c == '.' || c == '*' || (c >= '0' && c <= '9') {
+				i++
+				continue
+			}
+			break
+		}
+		if i >= len(format) {
+			break
+		}
+		arg++
+		if format[i] == 'w' {
+			indices = append(indices, arg)
+		}
+	}
+	return indices
+}
+
+// calledFunc returns the called function or method, nil for calls of function values
+func calledFunc(call *ast.CallExpr, info *types.Info) *types.Func {
+	var ident *ast.Ident
+	switch fun := ast.Unparen(call.Fun).(type) {
+	case *ast.Ident:
+		ident = fun
+	case *ast.SelectorExpr:
+		ident = fun.Sel
+	default:
+		return nil
+	}
+	fn, _ := info.Uses[ident].(*types.Func)
+	return fn
+}
+
+func isPkgFunc(fn *types.Func, pkgPath, name string) bool {
+	return fn.Pkg() != nil && fn.Pkg().Path() == pkgPath && fn.Name() == name &&
+		fn.Type().(*types.Signature).Recv() == nil
+}
+
+var errorInterface = errorType.Underlying().(*types.Interface)
@@ -5,6 +5,7 @@ go 1.24
 require (
 	golang.org/x/text v0.25.0
 	golang.org/x/tools v0.33.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
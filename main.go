@@ -2,53 +2,533 @@ package main
 
 import (
 	"collecterrs/collecterrs"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 )
 
+const defaultConfigPath = "collecterrs.yaml"
+
+// commands is the usage of the commands, printed by collecterrs -h
+const commands = `Usage:
+  collecterrs [flags]                  analyze the project and write the errors of the usecases
+  collecterrs diff [flags] baseline.json [current.json]
+  collecterrs diff [flags] -base ref [-head ref]
+                                       compare the errors with a baseline, fail on breaking changes
+  collecterrs proto [-check] [flags]   write the errors into the .proto files or check them
+  collecterrs gen [-lang go|ts|openapi] [flags]
+                                       generate the error definitions for the clients
+  collecterrs docs [-format html|markdown] [flags]
+                                       write the reference of the errors for people
+
+Run collecterrs <command> -h for the flags of the command.
+`
+
 func main() {
-	// Get the module name from the project's go.mod file
-	moduleName, err := getModuleName("project/go.mod")
+	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return // the usage is printed by the flag set
+		}
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// usage sets the usage of the command: the synopsis, then the flags
+func usage(fs *flag.FlagSet, synopsis string) {
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s\nFlags:\n", synopsis)
+		fs.PrintDefaults()
+	}
+}
+
+func run(args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "diff":
+			return runDiff(args[1:])
+		case "proto":
+			return runProto(args[1:])
+		case "gen":
+			return runGen(args[1:])
+		case "docs":
+			return runDocs(args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet("collecterrs", flag.ContinueOnError)
+	usage(fs, commands)
+	analysis := addAnalysisFlags(fs)
+	output := fs.String("o", "", "output file, - for stdout, project-errors.json, .txt, .dot or .mmd by the format by default")
+	format := fs.String("format", "json", "output format: json, text, dot, mermaid (call graph of the services)")
+	explain := fs.String("explain", "", "print the positions the error passes through from the RPC down to its return: service.usecase.code")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	if *explain != "" {
+		report, err := analysis.analyze()
+		if err != nil {
+			return err
+		}
+		return report.Explain(os.Stdout, *explain)
+	}
+
+	write, err := catalogueWriter(*format)
 	if err != nil {
-		fmt.Printf("error getting module name: %v\n", err)
-		return
+		return err
+	}
+	if *output == "" {
+		*output = map[string]string{"json": "project-errors.json", "text": "project-errors.txt", "dot": "project-errors.dot", "mermaid": "project-errors.mmd"}[*format]
 	}
 
-	ua := collecterrs.NewUsecaseAnalysis()
-	results, err := ua.Analyze("project/services", moduleName, false)
+	report, err := analysis.analyze()
 	if err != nil {
-		fmt.Printf("error analyzing usecases: %v\n", err)
-		return
+		return err
+	}
+
+	if err := writeOutput(*output, write, report); err != nil {
+		return err
+	}
+
+	// the output is written anyway, to see the diagnostics in it
+	if failed := report.Failed(); len(failed) > 0 {
+		for _, d := range failed {
+			fmt.Fprintf(os.Stderr, "%s\n", d)
+		}
+		return fmt.Errorf("%d provider calls are not resolved, acknowledge known external providers in the config externals", len(failed))
 	}
+	return nil
+}
 
-	output, _ := json.MarshalIndent(results, "", "  ")
+// runDiff compares the errors of the usecases of the baseline report with another report or the current tree:
+// collecterrs diff [flags] baseline.json [current.json]
+// or analyzes the trees of git revisions: collecterrs diff [flags] -base main [-head HEAD]
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("collecterrs diff", flag.ContinueOnError)
+	usage(fs, "Usage:\n  collecterrs diff [flags] baseline.json [current.json]\n  collecterrs diff [flags] -base ref [-head ref]\n")
+	analysis := addAnalysisFlags(fs)
+	format := fs.String("format", "text", "output format: text, json")
+	base := fs.String("base", "", "git revision of the baseline, analyzed instead of reading baseline.json")
+	head := fs.String("head", "", "git revision compared with -base, the current tree if not set")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-	// Save the output to a file
-	err = os.WriteFile("project-errors.json", output, 0644)
+	var baseline, current *collecterrs.Report
+	var err error
+	switch {
+	case *base != "":
+		if fs.NArg() > 0 {
+			return fmt.Errorf("usage: collecterrs diff [flags] -base ref [-head ref]")
+		}
+		if baseline, err = analysis.analyzeRevision(*base); err != nil {
+			return err
+		}
+		if *head != "" {
+			current, err = analysis.analyzeRevision(*head)
+		} else {
+			current, err = analysis.analyze()
+		}
+	case *head != "":
+		return fmt.Errorf("-head requires -base")
+	case fs.NArg() < 1 || fs.NArg() > 2:
+		return fmt.Errorf("usage: collecterrs diff [flags] baseline.json [current.json]")
+	default:
+		if baseline, err = readReport(fs.Arg(0)); err != nil {
+			return err
+		}
+		if fs.NArg() == 2 {
+			current, err = readReport(fs.Arg(1))
+		} else {
+			current, err = analysis.analyze()
+		}
+	}
 	if err != nil {
-		fmt.Printf("error writing to file: %v\n", err)
-		return
+		return err
 	}
 
-	fmt.Println("Results saved to project-errors.json")
+	changes := collecterrs.DiffCatalogues(baseline.Services, current.Services)
+	switch *format {
+	case "text":
+		err = collecterrs.WriteChanges(os.Stdout, changes)
+	case "json":
+		err = collecterrs.WriteChangesJSON(os.Stdout, changes)
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if breaking := collecterrs.Breaking(changes); len(breaking) > 0 {
+		return fmt.Errorf("%d breaking changes of the error contract", len(breaking))
+	}
+	return nil
 }
 
-// getModuleName reads the module name from a go.mod file
-func getModuleName(goModPath string) (string, error) {
-	content, err := os.ReadFile(goModPath)
+// runProto writes the analyzed errors of every rpc into the .proto files as (errors.possible) options,
+// or checks that the options match the errors: collecterrs proto [-check] [flags]
+func runProto(args []string) error {
+	fs := flag.NewFlagSet("collecterrs proto", flag.ContinueOnError)
+	usage(fs, "Usage:\n  collecterrs proto [-check] [flags]\n")
+	analysis := addAnalysisFlags(fs)
+	check := fs.Bool("check", false, "only check that the options of the .proto files match the analyzed errors")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	cfg, err := analysis.config()
+	if err != nil {
+		return err
+	}
+	report, err := analysis.analyzeConfig(cfg)
+	if err != nil {
+		return err
+	}
+	files, err := report.ProtoFiles()
+	if err != nil {
+		return err
+	}
+
+	var mismatches []collecterrs.ProtoMismatch
+	wd, _ := os.Getwd()
+	for _, file := range files {
+		if rel, err := filepath.Rel(wd, file.Path); err == nil && !strings.HasPrefix(rel, "..") {
+			file.Path = rel // shorter paths in the messages
+		}
+		updated, fileMismatches, err := file.Update(report.Services[file.Service], cfg.ProtoErrors)
+		if err != nil {
+			return err
+		}
+		mismatches = append(mismatches, fileMismatches...)
+		if *check || !file.Changed(updated) {
+			continue
+		}
+		if err := os.WriteFile(file.Path, updated, 0o644); err != nil {
+			return fmt.Errorf("writing to file: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Updated %s\n", file.Path)
+	}
+
+	if *check && len(mismatches) > 0 {
+		for _, m := range mismatches {
+			fmt.Fprintf(os.Stderr, "%s\n", m)
+		}
+		return fmt.Errorf("%d rpcs do not match the analyzed errors, run collecterrs proto to update the .proto files", len(mismatches))
+	}
+	return nil
+}
+
+// runGen generates the error definitions for the clients of the services from the analysis result:
+// collecterrs gen [-lang go|ts|openapi] [-report project-errors.json] [-o path] [flags]
+func runGen(args []string) error {
+	fs := flag.NewFlagSet("collecterrs gen", flag.ContinueOnError)
+	usage(fs, "Usage:\n  collecterrs gen [-lang go|ts|openapi] [-report project-errors.json] [-o path] [flags]\n")
+	analysis := addAnalysisFlags(fs)
+	lang := fs.String("lang", "go", "generated definitions: go (package specs/errors/<svc> per service), ts (TypeScript module), openapi (OpenAPI components)")
+	reportPath := fs.String("report", "", "report written with -format json, the project is analyzed if not set")
+	output := fs.String("o", "", "output directory for go, file for ts and openapi (- for stdout), in <module>/specs/errors by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	cfg, err := analysis.config()
+	if err != nil {
+		return err
+	}
+	var report *collecterrs.Report
+	if *reportPath != "" {
+		report, err = readReport(*reportPath)
+	} else {
+		report, err = analysis.analyzeConfig(cfg)
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to read go.mod file: %w", err)
+		return err
 	}
 
-	lines := strings.Split(string(content), "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "module ") {
-			return strings.TrimSpace(strings.TrimPrefix(line, "module ")), nil
+	dir := filepath.Join(cfg.ModuleDir, "specs", "errors")
+	var written []string
+	switch *lang {
+	case "go":
+		if *output != "" {
+			dir = *output
+		}
+		errsImport, err := cfg.ErrsImport()
+		if err != nil {
+			return err
+		}
+		genImport, err := cfg.ImportPath(dir)
+		if err != nil {
+			return err
+		}
+		if written, err = collecterrs.WriteGoErrors(report.Services, dir, errsImport, genImport); err != nil {
+			return fmt.Errorf("generating go errors: %w", err)
 		}
+	case "ts", "openapi":
+		path, write := filepath.Join(dir, "errors.ts"), collecterrs.WriteTypeScript
+		if *lang == "openapi" {
+			path, write = filepath.Join(dir, "errors.openapi.yaml"), collecterrs.WriteOpenAPI
+		}
+		if *output != "" {
+			path = *output
+		} else if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		return writeOutput(path, func(r *collecterrs.Report, w io.Writer) error { return write(w, r.Services) }, report)
+	default:
+		return fmt.Errorf("unknown language %q", *lang)
+	}
+	for _, path := range written {
+		fmt.Fprintf(os.Stderr, "Generated %s\n", path)
 	}
+	return nil
+}
 
-	return "", fmt.Errorf("module declaration not found in go.mod file")
+// runDocs writes the reference of the errors for people:
+// collecterrs docs [-format html|markdown] [-source url] [-report project-errors.json] [-o path] [flags]
+func runDocs(args []string) error {
+	fs := flag.NewFlagSet("collecterrs docs", flag.ContinueOnError)
+	usage(fs, "Usage:\n  collecterrs docs [-format html|markdown] [-source url] [-report project-errors.json] [-o path] [flags]\n")
+	analysis := addAnalysisFlags(fs)
+	format := fs.String("format", "html", "output format: html, markdown")
+	source := fs.String("source", "", "prefix of the links to the sources of the module, ex: https://git.example.com/repo/blob/main/project/, relative to the output by default")
+	reportPath := fs.String("report", "", "report written with -format json, the project is analyzed if not set")
+	output := fs.String("o", "", "output file, - for stdout, project-errors.html or project-errors.md by default")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+
+	var write func(*collecterrs.Docs, io.Writer) error
+	switch *format {
+	case "html":
+		write = (*collecterrs.Docs).WriteHTML
+	case "markdown":
+		write = (*collecterrs.Docs).WriteMarkdown
+	default:
+		return fmt.Errorf("unknown output format %q", *format)
+	}
+	if *output == "" {
+		*output = map[string]string{"html": "project-errors.html", "markdown": "project-errors.md"}[*format]
+	}
+
+	cfg, err := analysis.config()
+	if err != nil {
+		return err
+	}
+	var report *collecterrs.Report
+	if *reportPath != "" {
+		report, err = readReport(*reportPath)
+	} else {
+		report, err = analysis.analyzeConfig(cfg)
+	}
+	if err != nil {
+		return err
+	}
+
+	sourceURL := *source
+	if sourceURL == "" {
+		outputDir := "."
+		if *output != "-" {
+			outputDir = filepath.Dir(*output)
+		}
+		if sourceURL, err = relativeDir(outputDir, cfg.ModuleDir); err != nil {
+			return err
+		}
+	}
+	docs := collecterrs.NewDocs(report.Services, sourceURL)
+	return writeOutput(*output, func(_ *collecterrs.Report, w io.Writer) error { return write(docs, w) }, report)
+}
+
+// relativeDir returns the slash-separated path of the directory relative to the base directory
+func relativeDir(base, dir string) (string, error) {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return "", err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absBase, absDir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel) + "/", nil
+}
+
+// readReport reads the report written with -format json
+func readReport(path string) (*collecterrs.Report, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	report, err := collecterrs.ReadReport(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return report, nil
+}
+
+// analysisFlags are the flags of the project layout and the analysis, shared by the commands
+type analysisFlags struct {
+	configPath, root, services, layers, mode *string
+	verbose                                  *bool
+}
+
+func addAnalysisFlags(fs *flag.FlagSet) *analysisFlags {
+	return &analysisFlags{
+		configPath: fs.String("config", defaultConfigPath, "config file with the project layout"),
+		root:       fs.String("root", "", "directory of the analyzed module, containing go.mod"),
+		services:   fs.String("services", "", "glob of service directories, relative to the module root"),
+		layers:     fs.String("layers", "", "layer packages from the lowest one as package:Provider, the last one contains usecases, ex: storage:Storage,usecase"),
+		mode:       fs.String("mode", "", "analysis mode: ast, ssa (follows control flow)"),
+		verbose:    fs.Bool("v", false, "print debug information"),
+	}
+}
+
+// analyze loads the config, overrides it with the flags and analyzes the project
+func (f *analysisFlags) analyze() (*collecterrs.Report, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	return f.analyzeConfig(cfg)
+}
+
+// analyzeRevision analyzes the tree of the git revision, checked out into a temporary worktree.
+// The config is read from the current tree, the module directory is the same inside the worktree
+func (f *analysisFlags) analyzeRevision(ref string) (*collecterrs.Report, error) {
+	cfg, err := f.config()
+	if err != nil {
+		return nil, err
+	}
+	wt, err := collecterrs.NewWorktree(cfg.ModuleDir, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := wt.Remove(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}()
+	if cfg.ModuleDir, err = wt.Path(cfg.ModuleDir); err != nil {
+		return nil, err
+	}
+	if *f.verbose {
+		fmt.Fprintf(os.Stderr, "[DEBUG] Analyzing %s in %s\n", ref, cfg.ModuleDir)
+	}
+	report, err := f.analyzeConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", ref, err)
+	}
+	return report, nil
+}
+
+// config loads the config and overrides it with the flags
+func (f *analysisFlags) config() (collecterrs.Config, error) {
+	cfg, err := loadConfig(*f.configPath)
+	if err != nil {
+		return cfg, err
+	}
+	if *f.root != "" {
+		cfg.ModuleDir = *f.root
+	}
+	if *f.services != "" {
+		cfg.Services = *f.services
+	}
+	if *f.layers != "" {
+		cfg.Layers = parseLayers(*f.layers)
+	}
+	if *f.mode != "" {
+		cfg.Mode = *f.mode
+	}
+	return cfg, nil
+}
+
+// analyzeConfig analyzes the project and prints the warnings
+func (f *analysisFlags) analyzeConfig(cfg collecterrs.Config) (*collecterrs.Report, error) {
+	ua := collecterrs.NewUsecaseAnalysis()
+	report, err := ua.Analyze(cfg, *f.verbose)
+	if err != nil {
+		return nil, fmt.Errorf("analyzing usecases: %w", err)
+	}
+	for _, warning := range ua.Warnings() {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return report, nil
+}
+
+// writeOutput writes the report to the output file, - for stdout
+func writeOutput(output string, write func(*collecterrs.Report, io.Writer) error, report *collecterrs.Report) error {
+	if output == "-" {
+		return write(report, os.Stdout)
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("writing to file: %w", err)
+	}
+	if err := write(report, f); err != nil {
+		f.Close()
+		return fmt.Errorf("writing to file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("writing to file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Results saved to %s\n", output)
+	return nil
+}
+
+// catalogueWriter returns the writer of the requested output format
+func catalogueWriter(format string) (func(*collecterrs.Report, io.Writer) error, error) {
+	switch format {
+	case "json":
+		return (*collecterrs.Report).WriteJSON, nil
+	case "text":
+		return (*collecterrs.Report).WriteText, nil
+	case "dot":
+		return (*collecterrs.Report).WriteDOT, nil
+	case "mermaid":
+		return (*collecterrs.Report).WriteMermaid, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// loadConfig loads the config file. The default config file is optional
+func loadConfig(path string) (collecterrs.Config, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) && path == defaultConfigPath {
+		return collecterrs.DefaultConfig(), nil
+	}
+	return collecterrs.LoadConfig(path)
+}
+
+// parseLayers parses the layers flag: package:Provider,package:Provider,package
+func parseLayers(value string) []collecterrs.Layer {
+	var layers []collecterrs.Layer
+	for _, item := range strings.Split(value, ",") {
+		pkg, provider, _ := strings.Cut(strings.TrimSpace(item), ":")
+		layers = append(layers, collecterrs.Layer{Name: path.Base(pkg), Package: pkg, Provider: provider})
+	}
+	return layers
 }
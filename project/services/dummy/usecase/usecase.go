@@ -12,6 +12,7 @@ var _ Dummy = (*dummyImpl)(nil)
 
 type Dummy interface {
 	Dummy1(ctx context.Context)
+	Cases() error
 }
 
 type ServiceLocatorImpl struct {
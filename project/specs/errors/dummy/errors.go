@@ -0,0 +1,148 @@
+// Code generated by collecterrs. DO NOT EDIT.
+
+// Package dummyerrors описывает ошибки, которые могут вернуть методы сервиса dummy.
+package dummyerrors
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/status"
+	"your-company.com/project/errs/errsDummy"
+	"your-company.com/project/errs/errsOtp"
+	"your-company.com/project/pkg/errs"
+)
+
+var (
+	// DummyError: Ы
+	DummyError = errsDummy.DummyError
+	// FromVar1Error: Ы
+	FromVar1Error = errsDummy.FromVar1Error
+	// WithDetailsError: Ы. Details: foo
+	WithDetailsError = errsDummy.WithDetailsError
+	// FromVar2Error: Ы
+	FromVar2Error = errsDummy.FromVar2Error
+	// FromDepthError: Ы
+	FromDepthError = errsDummy.FromDepthError
+	// FromStorageUnhandledError: Ы
+	FromStorageUnhandledError = errsDummy.FromStorageUnhandledError
+)
+
+// CasesErrors are the errors Cases can return
+var CasesErrors casesErrors
+
+type casesErrors struct{}
+
+// IsDummyError checks that Cases returned dummy.DummyError: Ы
+func (casesErrors) IsDummyError(err error) bool {
+	return DummyError.Is(err)
+}
+
+// IsFromVar1Error checks that Cases returned dummy.FromVar1Error: Ы
+func (casesErrors) IsFromVar1Error(err error) bool {
+	return FromVar1Error.Is(err)
+}
+
+// IsWithDetailsError checks that Cases returned dummy.WithDetailsError: Ы
+func (casesErrors) IsWithDetailsError(err error) bool {
+	return WithDetailsError.Is(err)
+}
+
+// IsFromVar2Error checks that Cases returned dummy.FromVar2Error: Ы
+func (casesErrors) IsFromVar2Error(err error) bool {
+	return FromVar2Error.Is(err)
+}
+
+// IsFromDepthError checks that Cases returned dummy.FromDepthError: Ы
+func (casesErrors) IsFromDepthError(err error) bool {
+	return FromDepthError.Is(err)
+}
+
+// IsFromStorageUnhandledError checks that Cases returned dummy.FromStorageUnhandledError: Ы
+func (casesErrors) IsFromStorageUnhandledError(err error) bool {
+	return FromStorageUnhandledError.Is(err)
+}
+
+// IsAttemptNotFound checks that Cases returned otp.AttemptNotFound: Запрос для проверки кода не найден
+func (casesErrors) IsAttemptNotFound(err error) bool {
+	return errsOtp.AttemptNotFoundError.Is(err)
+}
+
+// IsInvalidCode checks that Cases returned otp.InvalidCode: Некорректный код подтверждения
+func (casesErrors) IsInvalidCode(err error) bool {
+	return errsOtp.InvalidCodeError.Is(err)
+}
+
+// IsMaxCodeChecksExceeded checks that Cases returned otp.MaxCodeChecksExceeded: Превышено количество проверок кода
+func (casesErrors) IsMaxCodeChecksExceeded(err error) bool {
+	return errsOtp.MaxCodeChecksExceededError.Is(err)
+}
+
+// All returns the errors Cases can return
+func (casesErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{DummyError, FromVar1Error, WithDetailsError, FromVar2Error, FromDepthError, FromStorageUnhandledError, errsOtp.AttemptNotFoundError, errsOtp.InvalidCodeError, errsOtp.MaxCodeChecksExceededError}
+}
+
+// Known checks that err is one of the errors Cases can return
+func (e casesErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error Cases returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (casesErrors) Switch(err error,
+	onDummyError func(errs.ServiceError) error,
+	onFromVar1Error func(errs.ServiceError) error,
+	onWithDetailsError func(errs.ServiceError) error,
+	onFromVar2Error func(errs.ServiceError) error,
+	onFromDepthError func(errs.ServiceError) error,
+	onFromStorageUnhandledError func(errs.ServiceError) error,
+	onAttemptNotFound func(errs.ServiceError) error,
+	onInvalidCode func(errs.ServiceError) error,
+	onMaxCodeChecksExceeded func(errs.ServiceError) error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	se := serviceError(err)
+	switch {
+	case DummyError.Equals(se):
+		return onDummyError(se)
+	case FromVar1Error.Equals(se):
+		return onFromVar1Error(se)
+	case WithDetailsError.Equals(se):
+		return onWithDetailsError(se)
+	case FromVar2Error.Equals(se):
+		return onFromVar2Error(se)
+	case FromDepthError.Equals(se):
+		return onFromDepthError(se)
+	case FromStorageUnhandledError.Equals(se):
+		return onFromStorageUnhandledError(se)
+	case errsOtp.AttemptNotFoundError.Equals(se):
+		return onAttemptNotFound(se)
+	case errsOtp.InvalidCodeError.Equals(se):
+		return onInvalidCode(se)
+	case errsOtp.MaxCodeChecksExceededError.Equals(se):
+		return onMaxCodeChecksExceeded(se)
+	}
+	return other(err)
+}
+
+// serviceError returns the ServiceError returned by the service itself or received over gRPC
+func serviceError(err error) errs.ServiceError {
+	var se errs.ServiceError
+	if errors.As(err, &se) {
+		return se
+	}
+	var sePtr *errs.ServiceError
+	if errors.As(err, &sePtr) && sePtr != nil {
+		return *sePtr
+	}
+	return errs.BuildFromGRPCStatus(status.Convert(err))
+}
@@ -0,0 +1,148 @@
+// Code generated by collecterrs. DO NOT EDIT.
+
+/** Тип ошибки: USER_RELATED_ERROR нужно обязательно обработать на фронте */
+export type ErrorType = "USER_RELATED_ERROR" | "INTERNAL_ERROR";
+
+/** Ответ сервиса с ошибкой, errs.ServerError */
+export interface ServerError<Code extends string = string, Details = Record<string, string>> {
+  code: Code;
+  message: string;
+  details?: Details;
+}
+
+/** Описание именованной ошибки */
+export interface ErrorInfo {
+  type: ErrorType;
+  description: string;
+  service: string;
+  details: string[];
+}
+
+/** Код неименованной ошибки, ее показывают как общую ошибку сервиса */
+export const InternalServiceError = "InternalServiceError";
+
+/** Коды ошибок dummy.Cases */
+export type DummyCasesErrorCode = "DummyError" | "FromVar1Error" | "WithDetailsError" | "FromVar2Error" | "FromDepthError" | "FromStorageUnhandledError" | "AttemptNotFound" | "InvalidCode" | "MaxCodeChecksExceeded";
+
+/** Ошибки dummy.Cases */
+export type DummyCasesError =
+  | ServerError<"DummyError">
+  | ServerError<"FromVar1Error">
+  | ServerError<"WithDetailsError", { "foo": string }>
+  | ServerError<"FromVar2Error">
+  | ServerError<"FromDepthError">
+  | ServerError<"FromStorageUnhandledError">
+  | ServerError<"AttemptNotFound">
+  | ServerError<"InvalidCode">
+  | ServerError<"MaxCodeChecksExceeded", { "max": string }>
+  | ServerError<typeof InternalServiceError>;
+
+export const dummyCasesErrors: Record<DummyCasesErrorCode, ErrorInfo> = {
+  "DummyError": { type: "USER_RELATED_ERROR", description: "Ы", service: "dummy", details: [] },
+  "FromVar1Error": { type: "USER_RELATED_ERROR", description: "Ы", service: "dummy", details: [] },
+  "WithDetailsError": { type: "USER_RELATED_ERROR", description: "Ы", service: "dummy", details: ["foo"] },
+  "FromVar2Error": { type: "USER_RELATED_ERROR", description: "Ы", service: "dummy", details: [] },
+  "FromDepthError": { type: "USER_RELATED_ERROR", description: "Ы", service: "dummy", details: [] },
+  "FromStorageUnhandledError": { type: "USER_RELATED_ERROR", description: "Ы", service: "dummy", details: [] },
+  "AttemptNotFound": { type: "USER_RELATED_ERROR", description: "Запрос для проверки кода не найден", service: "otp", details: [] },
+  "InvalidCode": { type: "USER_RELATED_ERROR", description: "Некорректный код подтверждения", service: "otp", details: [] },
+  "MaxCodeChecksExceeded": { type: "USER_RELATED_ERROR", description: "Превышено количество проверок кода", service: "otp", details: ["max"] },
+};
+
+/** Коды ошибок otp.GenerateCode */
+export type OtpGenerateCodeErrorCode = "MaxAttemptsExceeded" | "NewAttemptTimeNotExceeded";
+
+/** Ошибки otp.GenerateCode */
+export type OtpGenerateCodeError =
+  | ServerError<"MaxAttemptsExceeded">
+  | ServerError<"NewAttemptTimeNotExceeded">
+  | ServerError<typeof InternalServiceError>;
+
+export const otpGenerateCodeErrors: Record<OtpGenerateCodeErrorCode, ErrorInfo> = {
+  "MaxAttemptsExceeded": { type: "USER_RELATED_ERROR", description: "Превышено количество запросов кода", service: "otp", details: [] },
+  "NewAttemptTimeNotExceeded": { type: "USER_RELATED_ERROR", description: "Новый код возможен после ожидания", service: "otp", details: [] },
+};
+
+/** Коды ошибок otp.GenerateRetryCode */
+export type OtpGenerateRetryCodeErrorCode = "AttemptNotFound" | "MaxAttemptsExceeded" | "NewAttemptTimeNotExceeded";
+
+/** Ошибки otp.GenerateRetryCode */
+export type OtpGenerateRetryCodeError =
+  | ServerError<"AttemptNotFound">
+  | ServerError<"MaxAttemptsExceeded">
+  | ServerError<"NewAttemptTimeNotExceeded">
+  | ServerError<typeof InternalServiceError>;
+
+export const otpGenerateRetryCodeErrors: Record<OtpGenerateRetryCodeErrorCode, ErrorInfo> = {
+  "AttemptNotFound": { type: "USER_RELATED_ERROR", description: "Запрос для проверки кода не найден", service: "otp", details: [] },
+  "MaxAttemptsExceeded": { type: "USER_RELATED_ERROR", description: "Превышено количество запросов кода", service: "otp", details: [] },
+  "NewAttemptTimeNotExceeded": { type: "USER_RELATED_ERROR", description: "Новый код возможен после ожидания", service: "otp", details: [] },
+};
+
+/** Коды ошибок otp.HealthCheck */
+export type OtpHealthCheckErrorCode = never;
+
+/** Ошибки otp.HealthCheck */
+export type OtpHealthCheckError =
+  | ServerError<typeof InternalServiceError>;
+
+export const otpHealthCheckErrors: Record<OtpHealthCheckErrorCode, ErrorInfo> = {
+};
+
+/** Коды ошибок otp.ValidateCode */
+export type OtpValidateCodeErrorCode = "AttemptNotFound" | "InvalidCode" | "MaxCodeChecksExceeded";
+
+/** Ошибки otp.ValidateCode */
+export type OtpValidateCodeError =
+  | ServerError<"AttemptNotFound">
+  | ServerError<"InvalidCode">
+  | ServerError<"MaxCodeChecksExceeded", { "max": string }>
+  | ServerError<typeof InternalServiceError>;
+
+export const otpValidateCodeErrors: Record<OtpValidateCodeErrorCode, ErrorInfo> = {
+  "AttemptNotFound": { type: "USER_RELATED_ERROR", description: "Запрос для проверки кода не найден", service: "otp", details: [] },
+  "InvalidCode": { type: "USER_RELATED_ERROR", description: "Некорректный код подтверждения", service: "otp", details: [] },
+  "MaxCodeChecksExceeded": { type: "USER_RELATED_ERROR", description: "Превышено количество проверок кода", service: "otp", details: ["max"] },
+};
+
+/** Коды ошибок users.ConfirmLogin */
+export type UsersConfirmLoginErrorCode = "UserBlocked" | "AttemptNotFound" | "InvalidCode";
+
+/** Ошибки users.ConfirmLogin */
+export type UsersConfirmLoginError =
+  | ServerError<"UserBlocked">
+  | ServerError<"AttemptNotFound">
+  | ServerError<"InvalidCode">
+  | ServerError<typeof InternalServiceError>;
+
+export const usersConfirmLoginErrors: Record<UsersConfirmLoginErrorCode, ErrorInfo> = {
+  "UserBlocked": { type: "USER_RELATED_ERROR", description: "Пользователь заблокирован", service: "users", details: [] },
+  "AttemptNotFound": { type: "USER_RELATED_ERROR", description: "Запрос для проверки кода не найден", service: "otp", details: [] },
+  "InvalidCode": { type: "USER_RELATED_ERROR", description: "Некорректный код подтверждения", service: "otp", details: [] },
+};
+
+/** Коды ошибок users.HealthCheck */
+export type UsersHealthCheckErrorCode = never;
+
+/** Ошибки users.HealthCheck */
+export type UsersHealthCheckError =
+  | ServerError<typeof InternalServiceError>;
+
+export const usersHealthCheckErrors: Record<UsersHealthCheckErrorCode, ErrorInfo> = {
+};
+
+/** Коды ошибок users.Login */
+export type UsersLoginErrorCode = "UserBlocked" | "MaxAttemptsExceeded" | "NewAttemptTimeNotExceeded";
+
+/** Ошибки users.Login */
+export type UsersLoginError =
+  | ServerError<"UserBlocked">
+  | ServerError<"MaxAttemptsExceeded">
+  | ServerError<"NewAttemptTimeNotExceeded">
+  | ServerError<typeof InternalServiceError>;
+
+export const usersLoginErrors: Record<UsersLoginErrorCode, ErrorInfo> = {
+  "UserBlocked": { type: "USER_RELATED_ERROR", description: "Пользователь заблокирован", service: "users", details: [] },
+  "MaxAttemptsExceeded": { type: "USER_RELATED_ERROR", description: "Превышено количество запросов кода", service: "otp", details: [] },
+  "NewAttemptTimeNotExceeded": { type: "USER_RELATED_ERROR", description: "Новый код возможен после ожидания", service: "otp", details: [] },
+};
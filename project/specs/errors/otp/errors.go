@@ -0,0 +1,239 @@
+// Code generated by collecterrs. DO NOT EDIT.
+
+// Package otperrors описывает ошибки, которые могут вернуть методы сервиса otp.
+package otperrors
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/status"
+	"your-company.com/project/errs/errsOtp"
+	"your-company.com/project/pkg/errs"
+)
+
+var (
+	// MaxAttemptsExceeded: Превышено количество запросов кода
+	MaxAttemptsExceeded = errsOtp.MaxAttemptsExceededError
+	// NewAttemptTimeNotExceeded: Новый код возможен после ожидания
+	NewAttemptTimeNotExceeded = errsOtp.NewAttemptTimeNotExceededError
+	// AttemptNotFound: Запрос для проверки кода не найден
+	AttemptNotFound = errsOtp.AttemptNotFoundError
+	// InvalidCode: Некорректный код подтверждения
+	InvalidCode = errsOtp.InvalidCodeError
+	// MaxCodeChecksExceeded: Превышено количество проверок кода. Details: max
+	MaxCodeChecksExceeded = errsOtp.MaxCodeChecksExceededError
+)
+
+// GenerateCodeErrors are the errors GenerateCode can return
+var GenerateCodeErrors generateCodeErrors
+
+type generateCodeErrors struct{}
+
+// IsMaxAttemptsExceeded checks that GenerateCode returned otp.MaxAttemptsExceeded: Превышено количество запросов кода
+func (generateCodeErrors) IsMaxAttemptsExceeded(err error) bool {
+	return MaxAttemptsExceeded.Is(err)
+}
+
+// IsNewAttemptTimeNotExceeded checks that GenerateCode returned otp.NewAttemptTimeNotExceeded: Новый код возможен после ожидания
+func (generateCodeErrors) IsNewAttemptTimeNotExceeded(err error) bool {
+	return NewAttemptTimeNotExceeded.Is(err)
+}
+
+// All returns the errors GenerateCode can return
+func (generateCodeErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{MaxAttemptsExceeded, NewAttemptTimeNotExceeded}
+}
+
+// Known checks that err is one of the errors GenerateCode can return
+func (e generateCodeErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error GenerateCode returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (generateCodeErrors) Switch(err error,
+	onMaxAttemptsExceeded func(errs.ServiceError) error,
+	onNewAttemptTimeNotExceeded func(errs.ServiceError) error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	se := serviceError(err)
+	switch {
+	case MaxAttemptsExceeded.Equals(se):
+		return onMaxAttemptsExceeded(se)
+	case NewAttemptTimeNotExceeded.Equals(se):
+		return onNewAttemptTimeNotExceeded(se)
+	}
+	return other(err)
+}
+
+// GenerateRetryCodeErrors are the errors GenerateRetryCode can return
+var GenerateRetryCodeErrors generateRetryCodeErrors
+
+type generateRetryCodeErrors struct{}
+
+// IsAttemptNotFound checks that GenerateRetryCode returned otp.AttemptNotFound: Запрос для проверки кода не найден
+func (generateRetryCodeErrors) IsAttemptNotFound(err error) bool {
+	return AttemptNotFound.Is(err)
+}
+
+// IsMaxAttemptsExceeded checks that GenerateRetryCode returned otp.MaxAttemptsExceeded: Превышено количество запросов кода
+func (generateRetryCodeErrors) IsMaxAttemptsExceeded(err error) bool {
+	return MaxAttemptsExceeded.Is(err)
+}
+
+// IsNewAttemptTimeNotExceeded checks that GenerateRetryCode returned otp.NewAttemptTimeNotExceeded: Новый код возможен после ожидания
+func (generateRetryCodeErrors) IsNewAttemptTimeNotExceeded(err error) bool {
+	return NewAttemptTimeNotExceeded.Is(err)
+}
+
+// All returns the errors GenerateRetryCode can return
+func (generateRetryCodeErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{AttemptNotFound, MaxAttemptsExceeded, NewAttemptTimeNotExceeded}
+}
+
+// Known checks that err is one of the errors GenerateRetryCode can return
+func (e generateRetryCodeErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error GenerateRetryCode returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (generateRetryCodeErrors) Switch(err error,
+	onAttemptNotFound func(errs.ServiceError) error,
+	onMaxAttemptsExceeded func(errs.ServiceError) error,
+	onNewAttemptTimeNotExceeded func(errs.ServiceError) error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	se := serviceError(err)
+	switch {
+	case AttemptNotFound.Equals(se):
+		return onAttemptNotFound(se)
+	case MaxAttemptsExceeded.Equals(se):
+		return onMaxAttemptsExceeded(se)
+	case NewAttemptTimeNotExceeded.Equals(se):
+		return onNewAttemptTimeNotExceeded(se)
+	}
+	return other(err)
+}
+
+// HealthCheckErrors are the errors HealthCheck can return
+var HealthCheckErrors healthCheckErrors
+
+type healthCheckErrors struct{}
+
+// All returns the errors HealthCheck can return
+func (healthCheckErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{}
+}
+
+// Known checks that err is one of the errors HealthCheck can return
+func (e healthCheckErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error HealthCheck returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (healthCheckErrors) Switch(err error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	return other(err)
+}
+
+// ValidateCodeErrors are the errors ValidateCode can return
+var ValidateCodeErrors validateCodeErrors
+
+type validateCodeErrors struct{}
+
+// IsAttemptNotFound checks that ValidateCode returned otp.AttemptNotFound: Запрос для проверки кода не найден
+func (validateCodeErrors) IsAttemptNotFound(err error) bool {
+	return AttemptNotFound.Is(err)
+}
+
+// IsInvalidCode checks that ValidateCode returned otp.InvalidCode: Некорректный код подтверждения
+func (validateCodeErrors) IsInvalidCode(err error) bool {
+	return InvalidCode.Is(err)
+}
+
+// IsMaxCodeChecksExceeded checks that ValidateCode returned otp.MaxCodeChecksExceeded: Превышено количество проверок кода
+func (validateCodeErrors) IsMaxCodeChecksExceeded(err error) bool {
+	return MaxCodeChecksExceeded.Is(err)
+}
+
+// All returns the errors ValidateCode can return
+func (validateCodeErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{AttemptNotFound, InvalidCode, MaxCodeChecksExceeded}
+}
+
+// Known checks that err is one of the errors ValidateCode can return
+func (e validateCodeErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error ValidateCode returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (validateCodeErrors) Switch(err error,
+	onAttemptNotFound func(errs.ServiceError) error,
+	onInvalidCode func(errs.ServiceError) error,
+	onMaxCodeChecksExceeded func(errs.ServiceError) error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	se := serviceError(err)
+	switch {
+	case AttemptNotFound.Equals(se):
+		return onAttemptNotFound(se)
+	case InvalidCode.Equals(se):
+		return onInvalidCode(se)
+	case MaxCodeChecksExceeded.Equals(se):
+		return onMaxCodeChecksExceeded(se)
+	}
+	return other(err)
+}
+
+// serviceError returns the ServiceError returned by the service itself or received over gRPC
+func serviceError(err error) errs.ServiceError {
+	var se errs.ServiceError
+	if errors.As(err, &se) {
+		return se
+	}
+	var sePtr *errs.ServiceError
+	if errors.As(err, &sePtr) && sePtr != nil {
+		return *sePtr
+	}
+	return errs.BuildFromGRPCStatus(status.Convert(err))
+}
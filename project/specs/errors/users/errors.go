@@ -0,0 +1,181 @@
+// Code generated by collecterrs. DO NOT EDIT.
+
+// Package userserrors описывает ошибки, которые могут вернуть методы сервиса users.
+package userserrors
+
+import (
+	"errors"
+
+	"google.golang.org/grpc/status"
+	"your-company.com/project/errs/errsOtp"
+	"your-company.com/project/errs/errsUsers"
+	"your-company.com/project/pkg/errs"
+)
+
+var (
+	// UserBlocked: Пользователь заблокирован
+	UserBlocked = errsUsers.UserBlockedError
+)
+
+// ConfirmLoginErrors are the errors ConfirmLogin can return
+var ConfirmLoginErrors confirmLoginErrors
+
+type confirmLoginErrors struct{}
+
+// IsUserBlocked checks that ConfirmLogin returned users.UserBlocked: Пользователь заблокирован
+func (confirmLoginErrors) IsUserBlocked(err error) bool {
+	return UserBlocked.Is(err)
+}
+
+// IsAttemptNotFound checks that ConfirmLogin returned otp.AttemptNotFound: Запрос для проверки кода не найден
+func (confirmLoginErrors) IsAttemptNotFound(err error) bool {
+	return errsOtp.AttemptNotFoundError.Is(err)
+}
+
+// IsInvalidCode checks that ConfirmLogin returned otp.InvalidCode: Некорректный код подтверждения
+func (confirmLoginErrors) IsInvalidCode(err error) bool {
+	return errsOtp.InvalidCodeError.Is(err)
+}
+
+// All returns the errors ConfirmLogin can return
+func (confirmLoginErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{UserBlocked, errsOtp.AttemptNotFoundError, errsOtp.InvalidCodeError}
+}
+
+// Known checks that err is one of the errors ConfirmLogin can return
+func (e confirmLoginErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error ConfirmLogin returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (confirmLoginErrors) Switch(err error,
+	onUserBlocked func(errs.ServiceError) error,
+	onAttemptNotFound func(errs.ServiceError) error,
+	onInvalidCode func(errs.ServiceError) error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	se := serviceError(err)
+	switch {
+	case UserBlocked.Equals(se):
+		return onUserBlocked(se)
+	case errsOtp.AttemptNotFoundError.Equals(se):
+		return onAttemptNotFound(se)
+	case errsOtp.InvalidCodeError.Equals(se):
+		return onInvalidCode(se)
+	}
+	return other(err)
+}
+
+// HealthCheckErrors are the errors HealthCheck can return
+var HealthCheckErrors healthCheckErrors
+
+type healthCheckErrors struct{}
+
+// All returns the errors HealthCheck can return
+func (healthCheckErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{}
+}
+
+// Known checks that err is one of the errors HealthCheck can return
+func (e healthCheckErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error HealthCheck returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (healthCheckErrors) Switch(err error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	return other(err)
+}
+
+// LoginErrors are the errors Login can return
+var LoginErrors loginErrors
+
+type loginErrors struct{}
+
+// IsUserBlocked checks that Login returned users.UserBlocked: Пользователь заблокирован
+func (loginErrors) IsUserBlocked(err error) bool {
+	return UserBlocked.Is(err)
+}
+
+// IsMaxAttemptsExceeded checks that Login returned otp.MaxAttemptsExceeded: Превышено количество запросов кода
+func (loginErrors) IsMaxAttemptsExceeded(err error) bool {
+	return errsOtp.MaxAttemptsExceededError.Is(err)
+}
+
+// IsNewAttemptTimeNotExceeded checks that Login returned otp.NewAttemptTimeNotExceeded: Новый код возможен после ожидания
+func (loginErrors) IsNewAttemptTimeNotExceeded(err error) bool {
+	return errsOtp.NewAttemptTimeNotExceededError.Is(err)
+}
+
+// All returns the errors Login can return
+func (loginErrors) All() []errs.ServiceError {
+	return []errs.ServiceError{UserBlocked, errsOtp.MaxAttemptsExceededError, errsOtp.NewAttemptTimeNotExceededError}
+}
+
+// Known checks that err is one of the errors Login can return
+func (e loginErrors) Known(err error) bool {
+	for _, known := range e.All() {
+		if known.Is(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Switch calls the callback of the error Login returned, with the error details received over gRPC.
+// Other errors are passed to the last callback. A new possible error adds a callback,
+// so the callers are not built until they handle it
+func (loginErrors) Switch(err error,
+	onUserBlocked func(errs.ServiceError) error,
+	onMaxAttemptsExceeded func(errs.ServiceError) error,
+	onNewAttemptTimeNotExceeded func(errs.ServiceError) error,
+	other func(error) error,
+) error {
+	if err == nil {
+		return nil
+	}
+	se := serviceError(err)
+	switch {
+	case UserBlocked.Equals(se):
+		return onUserBlocked(se)
+	case errsOtp.MaxAttemptsExceededError.Equals(se):
+		return onMaxAttemptsExceeded(se)
+	case errsOtp.NewAttemptTimeNotExceededError.Equals(se):
+		return onNewAttemptTimeNotExceeded(se)
+	}
+	return other(err)
+}
+
+// serviceError returns the ServiceError returned by the service itself or received over gRPC
+func serviceError(err error) errs.ServiceError {
+	var se errs.ServiceError
+	if errors.As(err, &se) {
+		return se
+	}
+	var sePtr *errs.ServiceError
+	if errors.As(err, &sePtr) && sePtr != nil {
+		return *sePtr
+	}
+	return errs.BuildFromGRPCStatus(status.Convert(err))
+}
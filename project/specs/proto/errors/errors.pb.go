@@ -0,0 +1,85 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: specs/proto/errors/errors.proto
+
+package errors
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	reflect "reflect"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+var file_specs_proto_errors_errors_proto_extTypes = []protoimpl.ExtensionInfo{
+	{
+		ExtendedType:  (*descriptorpb.MethodOptions)(nil),
+		ExtensionType: ([]string)(nil),
+		Field:         50001,
+		Name:          "errors.possible",
+		Tag:           "bytes,50001,rep,name=possible",
+		Filename:      "specs/proto/errors/errors.proto",
+	},
+}
+
+// Extension fields to descriptorpb.MethodOptions.
+var (
+	// Коды именованных ошибок, которые может вернуть метод (errs.ServerError.Code).
+	// Заполняется командой `collecterrs proto` по результату анализа, не изменяйте вручную.
+	//
+	// repeated string possible = 50001;
+	E_Possible = &file_specs_proto_errors_errors_proto_extTypes[0]
+)
+
+var File_specs_proto_errors_errors_proto protoreflect.FileDescriptor
+
+const file_specs_proto_errors_errors_proto_rawDesc = "" +
+	"\n" +
+	"\x1fspecs/proto/errors/errors.proto\x12\x06errors\x1a google/protobuf/descriptor.proto:<\n" +
+	"\bpossible\x12\x1e.google.protobuf.MethodOptions\x18ц\x03 \x03(\tR\bpossibleB-Z+your-company.com/project/specs/proto/errorsb\x06proto3"
+
+var file_specs_proto_errors_errors_proto_goTypes = []any{
+	(*descriptorpb.MethodOptions)(nil), // 0: google.protobuf.MethodOptions
+}
+var file_specs_proto_errors_errors_proto_depIdxs = []int32{
+	0, // 0: errors.possible:extendee -> google.protobuf.MethodOptions
+	1, // [1:1] is the sub-list for method output_type
+	1, // [1:1] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	0, // [0:1] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_specs_proto_errors_errors_proto_init() }
+func file_specs_proto_errors_errors_proto_init() {
+	if File_specs_proto_errors_errors_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_specs_proto_errors_errors_proto_rawDesc), len(file_specs_proto_errors_errors_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   0,
+			NumExtensions: 1,
+			NumServices:   0,
+		},
+		GoTypes:           file_specs_proto_errors_errors_proto_goTypes,
+		DependencyIndexes: file_specs_proto_errors_errors_proto_depIdxs,
+		ExtensionInfos:    file_specs_proto_errors_errors_proto_extTypes,
+	}.Build()
+	File_specs_proto_errors_errors_proto = out.File
+	file_specs_proto_errors_errors_proto_goTypes = nil
+	file_specs_proto_errors_errors_proto_depIdxs = nil
+}
@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
+	_ "your-company.com/project/specs/proto/errors"
 )
 
 const (
@@ -414,7 +415,7 @@ var File_specs_proto_otp_otp_proto protoreflect.FileDescriptor
 
 const file_specs_proto_otp_otp_proto_rawDesc = "" +
 	"\n" +
-	"\x19specs/proto/otp/otp.proto\x12\x03otp\x1a\x19google/protobuf/any.proto\"\x10\n" +
+	"\x19specs/proto/otp/otp.proto\x12\x03otp\x1a\x19google/protobuf/any.proto\x1a\x1fspecs/proto/errors/errors.proto\"\x10\n" +
 	"\x0eHealthCheckReq\")\n" +
 	"\x0fHealthCheckResp\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\bR\x06status\"T\n" +
@@ -447,12 +448,12 @@ const file_specs_proto_otp_otp_proto_rawDesc = "" +
 	"\apayload\x18\n" +
 	" \x01(\fH\x00R\apayload\x88\x01\x01B\n" +
 	"\n" +
-	"\b_payload2\x80\x02\n" +
+	"\b_payload2\xbd\x03\n" +
 	"\x03Otp\x128\n" +
-	"\vHealthCheck\x12\x13.otp.HealthCheckReq\x1a\x14.otp.HealthCheckResp\x12;\n" +
-	"\fGenerateCode\x12\x14.otp.GenerateCodeReq\x1a\x15.otp.GenerateCodeResp\x12E\n" +
-	"\x11GenerateRetryCode\x12\x19.otp.GenerateRetryCodeReq\x1a\x15.otp.GenerateCodeResp\x12;\n" +
-	"\fValidateCode\x12\x14.otp.ValidateCodeReq\x1a\x15.otp.ValidateCodeRespB\x11Z\x0fspecs/proto/otpb\x06proto3"
+	"\vHealthCheck\x12\x13.otp.HealthCheckReq\x1a\x14.otp.HealthCheckResp\x12q\n" +
+	"\fGenerateCode\x12\x14.otp.GenerateCodeReq\x1a\x15.otp.GenerateCodeResp\"4\x8a\xb5\x18\x13MaxAttemptsExceeded\x8a\xb5\x18\x19NewAttemptTimeNotExceeded\x12\x8e\x01\n" +
+	"\x11GenerateRetryCode\x12\x19.otp.GenerateRetryCodeReq\x1a\x15.otp.GenerateCodeResp\"G\x8a\xb5\x18\x0fAttemptNotFound\x8a\xb5\x18\x13MaxAttemptsExceeded\x8a\xb5\x18\x19NewAttemptTimeNotExceeded\x12x\n" +
+	"\fValidateCode\x12\x14.otp.ValidateCodeReq\x1a\x15.otp.ValidateCodeResp\";\x8a\xb5\x18\x0fAttemptNotFound\x8a\xb5\x18\vInvalidCode\x8a\xb5\x18\x15MaxCodeChecksExceededB\x11Z\x0fspecs/proto/otpb\x06proto3"
 
 var (
 	file_specs_proto_otp_otp_proto_rawDescOnce sync.Once
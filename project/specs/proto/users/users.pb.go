@@ -12,6 +12,7 @@ import (
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
+	_ "your-company.com/project/specs/proto/errors"
 )
 
 const (
@@ -441,7 +442,7 @@ var File_specs_proto_users_users_proto protoreflect.FileDescriptor
 
 const file_specs_proto_users_users_proto_rawDesc = "" +
 	"\n" +
-	"\x1dspecs/proto/users/users.proto\x12\x05users\"\x10\n" +
+	"\x1dspecs/proto/users/users.proto\x12\x05users\x1a\x1fspecs/proto/errors/errors.proto\"\x10\n" +
 	"\x0eHealthCheckReq\")\n" +
 	"\x0fHealthCheckResp\x12\x16\n" +
 	"\x06status\x18\x01 \x01(\bR\x06status\"\xbc\x01\n" +
@@ -472,11 +473,11 @@ const file_specs_proto_users_users_proto_rawDesc = "" +
 	"\arefresh\x18\x02 \x01(\tR\arefresh\"R\n" +
 	"\x10ConfirmLoginResp\x12\"\n" +
 	"\x05token\x18\x01 \x01(\v2\f.users.TokenR\x05token\x12\x1a\n" +
-	"\bnextStep\x18\x02 \x01(\tR\bnextStep2\xb2\x01\n" +
+	"\bnextStep\x18\x02 \x01(\tR\bnextStep2\xaa\x02\n" +
 	"\x05Users\x12<\n" +
-	"\vHealthCheck\x12\x15.users.HealthCheckReq\x1a\x16.users.HealthCheckResp\x12*\n" +
-	"\x05Login\x12\x0f.users.LoginReq\x1a\x10.users.LoginResp\x12?\n" +
-	"\fConfirmLogin\x12\x16.users.ConfirmLoginReq\x1a\x17.users.ConfirmLoginRespB\x13Z\x11specs/proto/usersb\x06proto3"
+	"\vHealthCheck\x12\x15.users.HealthCheckReq\x1a\x16.users.HealthCheckResp\x12o\n" +
+	"\x05Login\x12\x0f.users.LoginReq\x1a\x10.users.LoginResp\"C\x8a\xb5\x18\vUserBlocked\x8a\xb5\x18\x13MaxAttemptsExceeded\x8a\xb5\x18\x19NewAttemptTimeNotExceeded\x12r\n" +
+	"\fConfirmLogin\x12\x16.users.ConfirmLoginReq\x1a\x17.users.ConfirmLoginResp\"1\x8a\xb5\x18\vUserBlocked\x8a\xb5\x18\x0fAttemptNotFound\x8a\xb5\x18\vInvalidCodeB\x13Z\x11specs/proto/usersb\x06proto3"
 
 var (
 	file_specs_proto_users_users_proto_rawDescOnce sync.Once